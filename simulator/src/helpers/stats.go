@@ -0,0 +1,83 @@
+package helpers
+
+import (
+	"math"
+	"sort"
+)
+
+// Stats is summary statistics of a distribution.
+type Stats struct {
+	Min    int     `json:"min"`
+	Max    int     `json:"max"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	P90    float64 `json:"p90"`
+	P99    float64 `json:"p99"`
+	StdDev float64 `json:"std_dev"`
+}
+
+// HistogramBin is the number of samples which have the same value.
+type HistogramBin struct {
+	Value int `json:"value"`
+	Count int `json:"count"`
+}
+
+// CalcStats returns summary statistics of values.
+// StdDev is population standard deviation.
+func CalcStats(values []int) Stats {
+	if len(values) == 0 {
+		return Stats{}
+	}
+	sorted := make([]int, len(values))
+	copy(sorted, values)
+	sort.Ints(sorted)
+
+	sum := 0.0
+	for _, v := range sorted {
+		sum += float64(v)
+	}
+	mean := sum / float64(len(sorted))
+
+	variance := 0.0
+	for _, v := range sorted {
+		d := float64(v) - mean
+		variance += d * d
+	}
+	variance /= float64(len(sorted))
+
+	return Stats{
+		Min:    sorted[0],
+		Max:    sorted[len(sorted)-1],
+		Mean:   mean,
+		Median: percentile(sorted, 50),
+		P90:    percentile(sorted, 90),
+		P99:    percentile(sorted, 99),
+		StdDev: math.Sqrt(variance),
+	}
+}
+
+// percentile returns p-th percentile of sorted values with linear interpolation.
+func percentile(sorted []int, p float64) float64 {
+	if len(sorted) == 1 {
+		return float64(sorted[0])
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	weight := rank - float64(lower)
+	return float64(sorted[lower])*(1-weight) + float64(sorted[upper])*weight
+}
+
+// Histogram returns the number of samples for each value in ascending order of value.
+func Histogram(values []int) []HistogramBin {
+	counts := map[int]int{}
+	for _, v := range values {
+		counts[v]++
+	}
+	bins := make([]HistogramBin, 0, len(counts))
+	for value, count := range counts {
+		bins = append(bins, HistogramBin{value, count})
+	}
+	sort.Slice(bins, func(i, j int) bool { return bins[i].Value < bins[j].Value })
+	return bins
+}
@@ -0,0 +1,98 @@
+package helpers
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestCalcStats(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []int
+		want   Stats
+	}{
+		{
+			name:   "empty",
+			values: nil,
+			want:   Stats{},
+		},
+		{
+			name:   "single value",
+			values: []int{7},
+			want:   Stats{Min: 7, Max: 7, Mean: 7, Median: 7, P90: 7, P99: 7, StdDev: 0},
+		},
+		{
+			name:   "unsorted values",
+			values: []int{4, 1, 3, 2},
+			// ranks of median, P90 and P99 are 1.5, 2.7 and 2.97.
+			want: Stats{Min: 1, Max: 4, Mean: 2.5, Median: 2.5, P90: 3.7, P99: 3.97, StdDev: math.Sqrt(1.25)},
+		},
+		{
+			name:   "repeated values",
+			values: []int{2, 4, 4, 4, 5, 5, 7, 9},
+			// ranks of median, P90 and P99 are 3.5, 6.3 and 6.93.
+			want: Stats{Min: 2, Max: 9, Mean: 5, Median: 4.5, P90: 7.6, P99: 8.86, StdDev: 2},
+		},
+	}
+	const epsilon = 1e-9
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := append([]int(nil), tt.values...)
+			got := CalcStats(values)
+			if got.Min != tt.want.Min || got.Max != tt.want.Max ||
+				math.Abs(got.Mean-tt.want.Mean) > epsilon ||
+				math.Abs(got.Median-tt.want.Median) > epsilon ||
+				math.Abs(got.P90-tt.want.P90) > epsilon ||
+				math.Abs(got.P99-tt.want.P99) > epsilon ||
+				math.Abs(got.StdDev-tt.want.StdDev) > epsilon {
+				t.Errorf("CalcStats(%v) = %+v, want %+v", tt.values, got, tt.want)
+			}
+			if !reflect.DeepEqual(values, tt.values) {
+				t.Errorf("CalcStats() reordered values to %v", values)
+			}
+		})
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		name   string
+		sorted []int
+		p      float64
+		want   float64
+	}{
+		{"single value", []int{3}, 90, 3},
+		{"lowest", []int{10, 20, 40}, 0, 10},
+		{"highest", []int{10, 20, 40}, 100, 40},
+		{"on a value", []int{10, 20, 40}, 50, 20},
+		{"between values", []int{10, 20, 40}, 25, 15},
+		{"between upper values", []int{10, 20, 40}, 90, 36},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.sorted, tt.p); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("percentile(%v, %v) = %v, want %v", tt.sorted, tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []int
+		want   []HistogramBin
+	}{
+		{"empty", nil, []HistogramBin{}},
+		{"single value", []int{5}, []HistogramBin{{5, 1}}},
+		{"repeated values", []int{3, 1, 3, 0, 3, 1}, []HistogramBin{{0, 1}, {1, 2}, {3, 3}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Histogram(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Histogram(%v) = %v, want %v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
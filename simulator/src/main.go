@@ -1,37 +1,14 @@
 package main
 
 import (
-	"encoding/hex"
-	"fmt"
 	"math/rand"
-	"os"
 	"time"
 	"trail_simulator/simulator/src/helpers"
 	"trail_simulator/simulator/src/models"
 	"trail_simulator/simulator/src/setting"
-	"trail_simulator/simulator/src/types"
+	"trail_simulator/simulator/src/workload"
 )
 
-var filePath string
-
-func init() {
-	filePath = "/go/src/trail_simulator/simulator/output/output_" + fmt.Sprint(time.Now().Unix()) + ".json"
-	file, err := os.Create(filePath)
-	if err != nil {
-		panic(err)
-	}
-	defer file.Close()
-	file.WriteString("{")
-	file.WriteString(
-		"\"setting\":" +
-			"{\"number_of_node\":" + fmt.Sprint(setting.NumberOfNode) +
-			",\"number_of_client\":" + fmt.Sprint(setting.NumberOfClient) +
-			",\"end_block_height\":" + fmt.Sprint(setting.EndBlockHeight) +
-			",\"archive_height\":" + fmt.Sprint(setting.ArchiveHeight) +
-			",\"inputs_per_block\":" + fmt.Sprint(setting.InputsPerBlock) +
-			"},\"blocks\":[")
-}
-
 func main() {
 	if setting.NumberOfNode == 0 {
 		panic("NumberOfNode must be larger than 0")
@@ -42,9 +19,15 @@ func main() {
 	if setting.NumberOfClient < setting.InputsPerBlock {
 		panic("NumberOfClient must be larger than InputsPerBlock")
 	}
-	if setting.TotalBalance/setting.NumberOfClient < setting.FeePerTXO {
+	if setting.GenesisFile == "" && setting.TotalBalance/setting.NumberOfClient < models.RequiredFee(1, 2) {
 		panic("initial balance must be larger than fee")
 	}
+	if setting.CheckpointMode != "none" && setting.CheckpointMode != "confirmation" && setting.CheckpointMode != "quorum" {
+		panic("CheckpointMode must be none, confirmation or quorum")
+	}
+	if setting.CheckpointMode == "quorum" && (setting.CheckpointQuorum == 0 || setting.CheckpointQuorum > setting.NumberOfNode) {
+		panic("CheckpointQuorum must be between 1 and NumberOfNode")
+	}
 
 	tb := helpers.CreateTimeBomb()
 	timer := helpers.CreateTimer()
@@ -53,13 +36,17 @@ func main() {
 	var clients []*models.Client
 	var nodes []*models.Node
 
-	var genesisTXOs []*models.TXO
-	addresses := types.List{}
+	allocations, err := models.UniformGenesis(setting.NumberOfClient, setting.TotalBalance)
+	if setting.GenesisFile != "" {
+		allocations, err = models.LoadGenesis(setting.GenesisFile, setting.NumberOfClient, setting.TotalBalance)
+	}
+	if err != nil {
+		panic(err)
+	}
 	parentHash := models.NullHash[0]
+	genesisTXOs := models.GenesisTXOs(allocations, parentHash)
 	for id := 0; id < setting.NumberOfClient; id++ {
-		addresses = append(addresses, id)
 		clients = append(clients, models.NewClient(uint32(id)))
-		genesisTXOs = append(genesisTXOs, models.NewTXOWithoutIndex(parentHash, uint32(id), setting.TotalBalance/setting.NumberOfClient))
 	}
 
 	for id := 0; id < setting.NumberOfNode; id++ {
@@ -69,147 +56,182 @@ func main() {
 	branches, newTXOs, usedTXOs, block := nodes[0].BuildGenesis(parentHash, genesisTXOs)
 
 	blockHash := block.Hash()
-	models.Blocks[blockHash] = block
-	branchIDs := map[models.BranchID]bool{}
-	for branchID, hash := range branches {
-		models.Branches[branchID] = models.NewBranch(branchID, blockHash, hash)
-		branchIDs[branchID] = true
-	}
+	branchIDs := models.StoreBlock(block, branches)
+	checker := models.NewInvariantChecker()
+	checker.AddBlock(blockHash, newTXOs, usedTXOs)
+	reference := models.NewReferenceTree()
 
 	for i := 0; i < setting.NumberOfClient; i++ {
 		clients[i].Update(branchIDs, newTXOs, usedTXOs, blockHash)
 	}
+	nextAddress := uint32(setting.NumberOfClient)
+	genesisStats := blockStats{Violations: checker.Check(blockHash, clients, setting.TotalBalance)}
+	if setting.ReferenceCheck {
+		reference.AddBlock(blockHash, newTXOs, usedTXOs)
+		genesisStats.Violations = append(genesisStats.Violations, reference.Compare(blockHash, branches)...)
+	}
+	outputBlockData(clients, nodes, *block, branchIDs, newTXOs, usedTXOs, genesisStats)
+
+	generator, err := workload.NewGenerator(clients)
+	if err != nil {
+		panic(err)
+	}
 
 	for block.Height < setting.EndBlockHeight {
 		tb.Start(5, "build tx")
 		rand.Seed(time.Now().UnixNano())
+		var stats blockStats
 		var txs []*models.Transaction
-		tmpAddresses := addresses
-		for i := 0; i < setting.InputsPerBlock; i += 2 {
-			r := rand.Intn(len(tmpAddresses))
-			index1 := tmpAddresses[r].(int)
-			tmpAddresses = tmpAddresses.Remove(r)
-			r = rand.Intn(len(tmpAddresses))
-			index2 := tmpAddresses[r].(int)
-			tmpAddresses = tmpAddresses.Remove(r)
-
-			client1 := clients[index1]
-			client2 := clients[index2]
-			tx, err := models.BuildTransaction(client1, client2)
-			if tx != nil && err == nil {
-				txs = append(txs, tx)
-			}
+		txs, stats.Workload = generator.Transactions(block.Height + 1)
+		tb.Clear()
 
-			if err != nil {
-				panic(err)
+		tb.Start(5, "broadcast tx")
+		for _, node := range nodes {
+			for _, tx := range txs {
+				node.Mempool.Add(tx, node.Client.HeadBlock)
 			}
 		}
 		tb.Clear()
 
 		tb.Start(5, "build block")
 		nodeID := rand.Intn(setting.NumberOfNode)
-		branches, newTXOs, usedTXOs, block = nodes[nodeID].BuildBlock(txs)
+		branches, newTXOs, usedTXOs, block = nodes[nodeID].BuildBlock(nodes[nodeID].Mempool.Sorted())
 		tb.Clear()
 
 		tb.Start(5, "update branches")
 		blockHash := block.Hash()
-		models.Blocks[blockHash] = block
-		branchIDs = map[models.BranchID]bool{}
-		for branchID, hash := range branches {
-			if _, exists := models.Branches[branchID]; exists {
-				if preHash, exists := models.Branches[branchID].Log[block.Parent]; !exists || preHash != hash {
-					models.Branches[branchID].AddUpdate(blockHash, hash)
-				}
-			} else {
-				models.Branches[branchID] = models.NewBranch(branchID, blockHash, hash)
-			}
-			branchIDs[branchID] = true
-		}
+		branchIDs = models.StoreBlock(block, branches)
+		checker.AddBlock(blockHash, newTXOs, usedTXOs)
 		tb.Clear()
 
 		tb.Start(10, "update client")
-		for i := 0; i < setting.NumberOfClient; i++ {
-			clients[i].Update(branchIDs, newTXOs, usedTXOs, blockHash)
+		for _, client := range clients {
+			client.Update(branchIDs, newTXOs, usedTXOs, blockHash)
+		}
+		tb.Clear()
+
+		tb.Start(5, "update mempool")
+		for _, node := range nodes {
+			node.Mempool.Update(usedTXOs, blockHash)
+			mempoolStats := node.Mempool.TakeStats()
+			if node.ID == uint32(nodeID) {
+				stats.Mempool = mempoolStats
+				stats.Build = node.Stats
+			}
 		}
 		tb.Clear()
 
 		tb.Start(5, "validation")
-		validation(clients, newTXOs)
+		stats.Violations = checker.Check(blockHash, clients, setting.TotalBalance)
+		if setting.ReferenceCheck {
+			reference.AddBlock(blockHash, newTXOs, usedTXOs)
+			stats.Violations = append(stats.Violations, reference.Compare(blockHash, branches)...)
+		}
 		tb.Clear()
 
-		outputBlockData(clients, *block, branchIDs, newTXOs, usedTXOs)
+		if setting.CheckpointMode != "none" {
+			tb.Start(5, "checkpoint")
+			stats.PrunedForkData = updateCheckpoint(nodes, clients, blockHash)
+			tb.Clear()
+		}
+		if setting.FinalityDepth > 0 {
+			tb.Start(5, "prune clients")
+			for _, client := range clients {
+				stats.PrunedClientData = stats.PrunedClientData.Add(client.PruneFinalized(setting.FinalityDepth))
+			}
+			tb.Clear()
+		}
+		if setting.BranchPruneDepth > 0 {
+			tb.Start(5, "prune branches")
+			stats.PrunedBranchLogEntries = models.PruneBranches(blockHash, setting.BranchPruneDepth)
+			tb.Clear()
+		}
+
+		if setting.JoinRate > 0 || setting.LeaveRate > 0 {
+			tb.Start(5, "churn")
+			clients, stats.Churn = churn(clients, nodes, generator, blockHash, &nextAddress)
+			tb.Clear()
+		}
+
+		outputBlockData(clients, nodes, *block, branchIDs, newTXOs, usedTXOs, stats)
 	}
 	timer.RecordLap()
 }
 
-func outputBlockData(clients []*models.Client, block models.Block, branchIDs map[models.BranchID]bool, newTXOs []*models.TXO, usedTXOs []*models.TXO) {
-	maxUnused := 0
-	maxUsed := 0
-	maxMemory := 0
-	maxArchive := 0
-	for _, client := range clients {
-		unusedSize := client.UnusedSize()
-		usedSize := client.UsedSize()
-		memorySize := client.MemorySize()
-		archiveSize := client.ArchiveSize()
-		if unusedSize > maxUnused {
-			maxUnused = unusedSize
-		}
-		if usedSize > maxUsed {
-			maxUsed = usedSize
+// occurrences returns random number of events whose mean is rate.
+func occurrences(rate float64) int {
+	n := int(rate)
+	if rand.Float64() < rate-float64(n) {
+		n++
+	}
+	return n
+}
+
+// churn lets clients except clients of nodes leave and new clients join at head block.
+// It returns clients which haven't left including new clients.
+func churn(clients []*models.Client, nodes []*models.Node, generator *workload.Generator, head [32]byte, nextAddress *uint32) ([]*models.Client, churnStats) {
+	var stats churnStats
+	nodeClients := map[*models.Client]bool{}
+	for _, node := range nodes {
+		nodeClients[node.Client] = true
+	}
+	for i := occurrences(setting.LeaveRate); i > 0; i-- {
+		var candidates []int
+		for j, client := range clients {
+			if !nodeClients[client] {
+				candidates = append(candidates, j)
+			}
 		}
-		if memorySize > maxMemory {
-			maxMemory = memorySize
+		if len(candidates) == 0 {
+			break
 		}
-		if archiveSize > maxArchive {
-			maxArchive = archiveSize
+		j := candidates[rand.Intn(len(candidates))]
+		for _, txo := range clients[j].AbandonedTXOs() {
+			stats.AbandonedTXOs++
+			stats.AbandonedBalance += txo.Balance
 		}
+		generator.Leave(clients[j])
+		clients = append(clients[:j], clients[j+1:]...)
+		stats.Left++
 	}
-	blockHash := block.Hash()
-	blockHashStr := hex.EncodeToString(blockHash[:8])
-	fmt.Println(block.Height, " ", blockHashStr)
-	fmt.Printf("unused %d, used %d, memory %d, storage %d\n", maxUnused, maxUsed, maxMemory, maxArchive)
-	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		panic(err)
-	}
-	defer file.Close()
-	record := fmt.Sprintf(
-		"{\"height\":%d,"+
-			"\"block_hash\":%q,"+
-			"\"number_of_updated_branchs\":%d,"+
-			"\"number_of_new_utxo\":%d,"+
-			"\"number_of_used_utxo\":%d,"+
-			"\"max_unused\":%d,"+
-			"\"max_used\":%d,"+
-			"\"max_memory\":%d,"+
-			"\"max_archiive\":%d}",
-		block.Height,
-		blockHashStr,
-		len(branchIDs),
-		len(newTXOs),
-		len(usedTXOs),
-		maxUnused,
-		maxUsed,
-		maxMemory,
-		maxArchive)
-	file.WriteString(record)
-	if block.Height == setting.EndBlockHeight {
-		file.WriteString("]}")
-	} else {
-		file.WriteString(",")
+	for i := occurrences(setting.JoinRate); i > 0; i-- {
+		client := models.NewClient(*nextAddress)
+		*nextAddress++
+		stats.Bootstrap = append(stats.Bootstrap, client.Bootstrap(head))
+		generator.Join(client)
+		clients = append(clients, client)
+		stats.Joined++
 	}
+	return clients, stats
 }
 
-func validation(clients []*models.Client, newTXOs []*models.TXO) {
-	for _, txo := range newTXOs {
-		for _, client := range clients {
-			if client.Address == txo.OwnerAddress {
-				_, err := client.BuildProof(txo)
-				if err != nil {
-					panic("validation:" + err.Error())
-				}
+// updateCheckpoint lets nodes agree on a new checkpoint and discards fork data below it.
+func updateCheckpoint(nodes []*models.Node, clients []*models.Client, head [32]byte) models.PrunedForkData {
+	var checkpoint *models.Checkpoint
+	switch setting.CheckpointMode {
+	case "confirmation":
+		checkpoint = models.ConfirmByDepth(head, setting.CheckpointDepth)
+	case "quorum":
+		headBlock := models.Blocks[head]
+		if headBlock.Height >= setting.CheckpointDepth {
+			height := headBlock.Height - setting.CheckpointDepth
+			if height%setting.CheckpointInterval == 0 {
+				checkpoint = models.ConfirmCheckpoint(nodes, height, setting.CheckpointQuorum)
 			}
 		}
 	}
+	if checkpoint == nil {
+		return models.PrunedForkData{}
+	}
+	heads := map[[32]byte]bool{}
+	for _, client := range clients {
+		heads[client.HeadBlock] = true
+	}
+	forks, pruned := models.PruneForks(heads)
+	if len(forks) > 0 {
+		for _, client := range clients {
+			client.DropBlocks(forks)
+		}
+	}
+	return pruned
 }
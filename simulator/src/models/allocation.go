@@ -0,0 +1,77 @@
+package models
+
+import (
+	"errors"
+	"sort"
+)
+
+// IndexAllocator decides the order in which new TXOs of a block are assigned leaf indexes.
+type IndexAllocator interface {
+	// Order returns outputs of transactions and reward TXO in order of leaf index.
+	Order(outputs []*TXO, reward *TXO) []*TXO
+}
+
+type sequentialAllocator struct{}
+type ownerAllocator struct{}
+type addressAllocator struct{}
+type rewardLastAllocator struct{}
+
+// NewIndexAllocator returns index allocator of the policy.
+// Policy is one of "sequential", "owner", "address" and "reward-last".
+func NewIndexAllocator(policy string) (IndexAllocator, error) {
+	switch policy {
+	case "sequential":
+		return sequentialAllocator{}, nil
+	case "owner":
+		return ownerAllocator{}, nil
+	case "address":
+		return addressAllocator{}, nil
+	case "reward-last":
+		return rewardLastAllocator{}, nil
+	}
+	return nil, errors.New("NewIndexAllocator: unknown policy " + policy)
+}
+
+// groupByOwner returns TXOs grouped by owner in order of first appearance of owner.
+func groupByOwner(txos []*TXO) []*TXO {
+	first := map[uint32]int{}
+	for i, txo := range txos {
+		if _, exists := first[txo.OwnerAddress]; !exists {
+			first[txo.OwnerAddress] = i
+		}
+	}
+	grouped := append([]*TXO{}, txos...)
+	sort.SliceStable(grouped, func(i, j int) bool {
+		return first[grouped[i].OwnerAddress] < first[grouped[j].OwnerAddress]
+	})
+	return grouped
+}
+
+// sortByAddress returns TXOs in ascending order of owner address.
+func sortByAddress(txos []*TXO) []*TXO {
+	sorted := append([]*TXO{}, txos...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].OwnerAddress < sorted[j].OwnerAddress
+	})
+	return sorted
+}
+
+// Order returns outputs in order of transactions followed by reward TXO.
+func (a sequentialAllocator) Order(outputs []*TXO, reward *TXO) []*TXO {
+	return append(append([]*TXO{}, outputs...), reward)
+}
+
+// Order returns outputs and reward TXO grouped by owner.
+func (a ownerAllocator) Order(outputs []*TXO, reward *TXO) []*TXO {
+	return groupByOwner(append(append([]*TXO{}, outputs...), reward))
+}
+
+// Order returns outputs and reward TXO sorted by owner address.
+func (a addressAllocator) Order(outputs []*TXO, reward *TXO) []*TXO {
+	return sortByAddress(append(append([]*TXO{}, outputs...), reward))
+}
+
+// Order returns outputs sorted by owner address followed by reward TXO.
+func (a rewardLastAllocator) Order(outputs []*TXO, reward *TXO) []*TXO {
+	return append(sortByAddress(outputs), reward)
+}
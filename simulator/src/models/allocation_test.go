@@ -0,0 +1,46 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIndexAllocator_Order(t *testing.T) {
+	// outputs are identified by balances 1 to 5, and reward by balance 100.
+	owners := []uint32{3, 1, 3, 2, 1}
+	tests := []struct {
+		policy string
+		want   []uint64 // balances in order of leaf index.
+	}{
+		{"sequential", []uint64{1, 2, 3, 4, 5, 100}},
+		{"owner", []uint64{1, 3, 2, 5, 100, 4}},
+		{"address", []uint64{2, 5, 100, 4, 1, 3}},
+		{"reward-last", []uint64{2, 5, 4, 1, 3, 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			allocator, err := NewIndexAllocator(tt.policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var outputs []*TXO
+			for i, owner := range owners {
+				outputs = append(outputs, NewTXOWithoutIndex(NullHash[0], owner, uint64(i+1)))
+			}
+			given := append([]*TXO{}, outputs...)
+			var got []uint64
+			for _, txo := range allocator.Order(outputs, NewTXOWithoutIndex(NullHash[0], 1, 100)) {
+				got = append(got, txo.Balance)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Order() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(outputs, given) {
+				t.Error("Order() reordered given outputs")
+			}
+		})
+	}
+	if _, err := NewIndexAllocator("random"); err == nil {
+		t.Error("NewIndexAllocator() of unknown policy succeeded")
+	}
+}
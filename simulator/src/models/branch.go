@@ -1,5 +1,13 @@
 package models
 
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"trail_simulator/simulator/src/types"
+)
+
 // Branch records blockHash which this branch hash is updated and the updated branch hash value.
 type Branch struct {
 	ID  BranchID
@@ -19,6 +27,55 @@ func BuildBranchID(height byte, index [32]byte) BranchID {
 	return branchID
 }
 
+// Height returns the height of the node branchID identifies.
+func (id BranchID) Height() uint8 {
+	return id[0]
+}
+
+// Index returns the index in height of the node branchID identifies.
+func (id BranchID) Index() types.Uint256 {
+	var index types.Uint256
+	copy(index[:], id[1:])
+	return index
+}
+
+// String returns branchID as height and index in hex joined by colon, e.g. "3:1f".
+func (id BranchID) String() string {
+	return strconv.Itoa(int(id.Height())) + ":" + id.Index().String()
+}
+
+// ParseBranchID parses branchID in the format String returns.
+func ParseBranchID(s string) (BranchID, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return BranchID{}, errors.New("ParseBranchID: invalid branch id " + s)
+	}
+	height, err := strconv.ParseUint(parts[0], 10, 8)
+	if err != nil || height >= RootHeight {
+		return BranchID{}, errors.New("ParseBranchID: invalid height " + s)
+	}
+	var index types.Uint256
+	if err := index.UnmarshalText([]byte(parts[1])); err != nil {
+		return BranchID{}, errors.New("ParseBranchID: invalid index " + s)
+	}
+	return BuildBranchID(uint8(height), index), nil
+}
+
+// MarshalText implements encoding.TextMarshaler, which JSON uses for values and map keys.
+func (id BranchID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (id *BranchID) UnmarshalText(text []byte) error {
+	parsed, err := ParseBranchID(string(text))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
 // NewBranch provides new branch instance.
 func NewBranch(id BranchID, blockHash [32]byte, branchHash [32]byte) *Branch {
 	return &Branch{id, map[[32]byte][32]byte{blockHash: branchHash}}
@@ -28,3 +85,15 @@ func NewBranch(id BranchID, blockHash [32]byte, branchHash [32]byte) *Branch {
 func (b *Branch) AddUpdate(blockHash [32]byte, branchHash [32]byte) {
 	b.Log[blockHash] = branchHash
 }
+
+// MarshalJSON encodes branch with its log keyed by block hash, all hashes in hex.
+func (b Branch) MarshalJSON() ([]byte, error) {
+	log := map[string]string{}
+	for blockHash, branchHash := range b.Log {
+		log[HashString(blockHash)] = HashString(branchHash)
+	}
+	return json.Marshal(struct {
+		ID  BranchID          `json:"id"`
+		Log map[string]string `json:"log"`
+	}{b.ID, log})
+}
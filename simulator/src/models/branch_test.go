@@ -0,0 +1,74 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"trail_simulator/simulator/src/types"
+)
+
+func TestParseBranchID(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    BranchID
+		wantErr bool
+	}{
+		{
+			name: "leaf",
+			s:    "0:1f",
+			want: BuildBranchID(0, types.Uint256{0x1f}),
+		},
+		{
+			name: "highest branch",
+			s:    "254:0x0",
+			want: BuildBranchID(254, types.Uint256{}),
+		},
+		{
+			name:    "root is not a branch",
+			s:       "255:0",
+			wantErr: true,
+		},
+		{
+			name:    "no height",
+			s:       "1f",
+			wantErr: true,
+		},
+		{
+			name:    "invalid index",
+			s:       "3:xyz",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBranchID(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBranchID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseBranchID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBranch_MarshalJSON(t *testing.T) {
+	blockHash, branchHash := [32]byte{1}, [32]byte{2}
+	data, err := json.Marshal(map[BranchID]*Branch{
+		BuildBranchID(3, types.Uint256{0x1f}): NewBranch(BuildBranchID(3, types.Uint256{0x1f}), blockHash, branchHash)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"3:1f":{"id":"3:1f","log":{"01` + strings.Repeat("0", 62) + `":"02` + strings.Repeat("0", 62) + `"}}}`
+	if string(data) != want {
+		t.Errorf("json.Marshal() = %s, want %s", data, want)
+	}
+	parsed, err := ParseHash(HashString(blockHash))
+	if err != nil || parsed != blockHash {
+		t.Errorf("ParseHash() = %v, %v, want %v", parsed, err, blockHash)
+	}
+	if ShortHashString(blockHash) != HashString(blockHash)[:16] {
+		t.Errorf("ShortHashString() = %s is not prefix of HashString()", ShortHashString(blockHash))
+	}
+}
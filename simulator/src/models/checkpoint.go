@@ -0,0 +1,206 @@
+package models
+
+// Checkpoint is a block nodes agreed as final.
+// Nodes and clients reject blocks whose chain doesn't include the latest checkpoint.
+type Checkpoint struct {
+	Height    uint64
+	BlockHash [32]byte
+	Signers   []uint32 // ids of nodes signed this checkpoint.
+}
+
+// Checkpoints is all of agreed checkpoints in order of height.
+var Checkpoints []*Checkpoint
+
+// LatestCheckpoint returns the latest checkpoint. It returns nil if there is no checkpoint.
+func LatestCheckpoint() *Checkpoint {
+	if len(Checkpoints) == 0 {
+		return nil
+	}
+	return Checkpoints[len(Checkpoints)-1]
+}
+
+// ancestorAt returns hash of the ancestor of the block at the height.
+func ancestorAt(blockHash [32]byte, height uint64) ([32]byte, bool) {
+	block, exists := Blocks[blockHash]
+	for exists && block.Height > height {
+		blockHash = block.Parent
+		block, exists = Blocks[blockHash]
+	}
+	if !exists || block.Height != height {
+		return [32]byte{}, false
+	}
+	return blockHash, true
+}
+
+// OnCheckpointChain returns whether the chain of the block includes the latest checkpoint.
+// Blocks lower than the latest checkpoint are never on checkpoint chain except its ancestors.
+// Unknown blocks, including the ones discarded by PruneForks, aren't on checkpoint chain.
+func OnCheckpointChain(blockHash [32]byte) bool {
+	checkpoint := LatestCheckpoint()
+	if checkpoint == nil {
+		return true
+	}
+	block, exists := Blocks[blockHash]
+	if !exists {
+		return false
+	}
+	if block.Height < checkpoint.Height {
+		ancestor, exists := ancestorAt(checkpoint.BlockHash, block.Height)
+		return exists && ancestor == blockHash
+	}
+	ancestor, exists := ancestorAt(blockHash, checkpoint.Height)
+	return exists && ancestor == checkpoint.BlockHash
+}
+
+// SignCheckpoint returns hash of the block at the height on the chain node follows.
+// It returns false if node's chain doesn't reach the height.
+func (n *Node) SignCheckpoint(height uint64) ([32]byte, bool) {
+	return ancestorAt(n.Client.HeadBlock, height)
+}
+
+// ConfirmCheckpoint collects signatures of nodes for the height and
+// adds a checkpoint if quorum of nodes signed the same block.
+// It returns the new checkpoint or nil if no checkpoint is added.
+func ConfirmCheckpoint(nodes []*Node, height uint64, quorum int) *Checkpoint {
+	if latest := LatestCheckpoint(); latest != nil && latest.Height >= height {
+		return nil
+	}
+	signers := map[[32]byte][]uint32{}
+	for _, node := range nodes {
+		if blockHash, ok := node.SignCheckpoint(height); ok {
+			signers[blockHash] = append(signers[blockHash], node.ID)
+		}
+	}
+	for blockHash, ids := range signers {
+		if len(ids) >= quorum && OnCheckpointChain(blockHash) {
+			checkpoint := &Checkpoint{height, blockHash, ids}
+			Checkpoints = append(Checkpoints, checkpoint)
+			return checkpoint
+		}
+	}
+	return nil
+}
+
+// ConfirmByDepth adds a checkpoint at the block depth blocks before head block.
+// It returns the new checkpoint or nil if no checkpoint is added.
+func ConfirmByDepth(head [32]byte, depth uint64) *Checkpoint {
+	headBlock := Blocks[head]
+	if headBlock.Height < depth {
+		return nil
+	}
+	height := headBlock.Height - depth
+	if latest := LatestCheckpoint(); latest != nil && latest.Height >= height {
+		return nil
+	}
+	blockHash, ok := ancestorAt(head, height)
+	if !ok || !OnCheckpointChain(blockHash) {
+		return nil
+	}
+	checkpoint := &Checkpoint{height, blockHash, nil}
+	Checkpoints = append(Checkpoints, checkpoint)
+	return checkpoint
+}
+
+// checkpointChain memoizes whether blocks are on the chain of a checkpoint,
+// so that classifying all blocks walks each block once instead of once per descendant.
+type checkpointChain struct {
+	height  uint64
+	onChain map[[32]byte]bool
+}
+
+// newCheckpointChain walks from the checkpoint to genesis once and marks the blocks on the way.
+func newCheckpointChain(checkpoint *Checkpoint) checkpointChain {
+	onChain := map[[32]byte]bool{}
+	blockHash := checkpoint.BlockHash
+	for block, exists := Blocks[blockHash]; exists; block, exists = Blocks[blockHash] {
+		onChain[blockHash] = true
+		blockHash = block.Parent
+	}
+	return checkpointChain{checkpoint.Height, onChain}
+}
+
+// contains returns whether the block is on the chain, same as OnCheckpointChain.
+// Blocks not higher than the checkpoint are on the chain only if they were marked,
+// and higher blocks are on the chain if their first known ancestor is.
+// The result is memoized for the block and the ancestors walked.
+func (c checkpointChain) contains(blockHash [32]byte) bool {
+	var walked [][32]byte
+	onChain := false
+	for {
+		if known, exists := c.onChain[blockHash]; exists {
+			onChain = known
+			break
+		}
+		block, exists := Blocks[blockHash]
+		if !exists {
+			break
+		}
+		walked = append(walked, blockHash)
+		if block.Height <= c.height {
+			break
+		}
+		blockHash = block.Parent
+	}
+	for _, blockHash := range walked {
+		c.onChain[blockHash] = onChain
+	}
+	return onChain
+}
+
+// PrunedForkData is amount of fork data discarded below checkpoint.
+type PrunedForkData struct {
+	Blocks           int `json:"blocks"`
+	BranchLogEntries int `json:"branch_log_entries"`
+	Bytes            int `json:"bytes"` // in serialized representation.
+}
+
+// PruneForks discards blocks which aren't on the chain of the latest checkpoint and their branch updates.
+// Head blocks of clients and their ancestors are kept, so that clients following a fork
+// can still switch to checkpoint chain. They are discarded once no client follows the fork.
+// PruneForks returns hash values of discarded blocks.
+func PruneForks(heads map[[32]byte]bool) (map[[32]byte]bool, PrunedForkData) {
+	var pruned PrunedForkData
+	forks := map[[32]byte]bool{}
+	if LatestCheckpoint() == nil {
+		return forks, pruned
+	}
+	chain := newCheckpointChain(LatestCheckpoint())
+	followed := map[[32]byte]bool{}
+	for head := range heads {
+		for blockHash := head; !followed[blockHash] && !chain.contains(blockHash); {
+			block, exists := Blocks[blockHash]
+			if !exists {
+				break
+			}
+			followed[blockHash] = true
+			blockHash = block.Parent
+		}
+	}
+	for blockHash := range Blocks {
+		if !chain.contains(blockHash) && !followed[blockHash] {
+			forks[blockHash] = true
+		}
+	}
+	if len(forks) == 0 {
+		return forks, pruned
+	}
+
+	for branchID, branch := range Branches {
+		for blockHash := range branch.Log {
+			if forks[blockHash] {
+				delete(branch.Log, blockHash)
+				pruned.BranchLogEntries++
+			}
+		}
+		if len(branch.Log) == 0 {
+			delete(Branches, branchID)
+		}
+	}
+	for blockHash := range forks {
+		delete(Blocks, blockHash)
+	}
+	pruned.Blocks = len(forks)
+	pruned.Bytes = pruned.Blocks*(SerializedSize.BlockHash+SerializedSize.Header) +
+		pruned.BranchLogEntries*(SerializedSize.BlockHash+SerializedSize.Hash)
+	return forks, pruned
+}
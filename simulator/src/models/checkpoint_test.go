@@ -0,0 +1,86 @@
+package models
+
+import "testing"
+
+func TestPruneForks(t *testing.T) {
+	defer resetGlobals()()
+	nodes := []*Node{NewNode(0, NewClient(0)), NewNode(1, NewClient(1))}
+	clients := []*Client{nodes[0].Client, nodes[1].Client}
+	checker := NewInvariantChecker()
+	// deliver stores block and delivers it to the clients.
+	deliver := func(branches map[BranchID][32]byte, newTXOs []*TXO, usedTXOs []*TXO, block *Block, clients ...*Client) [32]byte {
+		blockHash := block.Hash()
+		branchIDs := StoreBlock(block, branches)
+		checker.AddBlock(blockHash, newTXOs, usedTXOs)
+		for _, client := range clients {
+			client.Update(branchIDs, newTXOs, usedTXOs, blockHash)
+		}
+		return blockHash
+	}
+	build := func(node *Node, clients ...*Client) [32]byte {
+		branches, newTXOs, usedTXOs, block := node.BuildBlock(nil)
+		return deliver(branches, newTXOs, usedTXOs, block, clients...)
+	}
+	heads := func() map[[32]byte]bool {
+		return map[[32]byte]bool{clients[0].HeadBlock: true, clients[1].HeadBlock: true}
+	}
+
+	genesisTXOs := []*TXO{NewTXOWithoutIndex(NullHash[0], 0, 1000), NewTXOWithoutIndex(NullHash[0], 1, 1000)}
+	branches, newTXOs, usedTXOs, block := nodes[0].BuildGenesis(NullHash[0], genesisTXOs)
+	deliver(branches, newTXOs, usedTXOs, block, clients...)
+	// node 1 follows a fork of a block the other node doesn't receive.
+	build(nodes[0], clients[0])
+	fork := build(nodes[1], clients[1])
+	head := build(nodes[0], clients[0])
+	if ConfirmByDepth(head, 1) == nil {
+		t.Fatal("ConfirmByDepth() added no checkpoint")
+	}
+
+	forks, _ := PruneForks(heads())
+	if len(forks) != 0 || Blocks[fork] == nil {
+		t.Fatalf("PruneForks() discarded head block of client following the fork")
+	}
+	if OnCheckpointChain([32]byte{1}) {
+		t.Error("OnCheckpointChain() of unknown block = true")
+	}
+	func() {
+		defer func() {
+			if r := recover(); r != "BuildBlock: parent block is not on checkpoint chain" {
+				t.Errorf("BuildBlock() on the fork panics with %v", r)
+			}
+		}()
+		nodes[1].BuildBlock(nil)
+	}()
+
+	// node 1 switches to checkpoint chain by receiving the next block, then the fork is discarded.
+	head = build(nodes[0], clients...)
+	if clients[1].HeadBlock != head {
+		t.Fatal("client following the fork doesn't switch to checkpoint chain")
+	}
+	forks, _ = PruneForks(heads())
+	if !forks[fork] || Blocks[fork] != nil {
+		t.Fatal("PruneForks() didn't discard the fork no client follows")
+	}
+	for _, client := range clients {
+		client.DropBlocks(forks)
+	}
+	head = build(nodes[1], clients...)
+	if violations := checker.Check(head, clients, 2000); len(violations) != 0 {
+		t.Errorf("InvariantChecker.Check() = %+v", violations)
+	}
+}
+
+func TestCheckpointChain_Contains(t *testing.T) {
+	defer resetGlobals()()
+	tree := buildTree(1, 3, 30)
+	for _, checkpoint := range tree {
+		Checkpoints = []*Checkpoint{{Blocks[checkpoint.hash].Height, checkpoint.hash, nil}}
+		chain := newCheckpointChain(LatestCheckpoint())
+		for _, b := range append(tree, &treeBlock{hash: [32]byte{1}}) {
+			if got, want := chain.contains(b.hash), OnCheckpointChain(b.hash); got != want {
+				t.Errorf("checkpointChain.contains(%x) = %v, want %v with checkpoint %x",
+					b.hash, got, want, checkpoint.hash)
+			}
+		}
+	}
+}
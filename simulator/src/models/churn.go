@@ -0,0 +1,42 @@
+package models
+
+import "trail_simulator/simulator/src/types"
+
+// BootstrapCost is data a client joining mid-simulation downloads from full node.
+type BootstrapCost struct {
+	Height  uint64 `json:"height"`  // height of head block when client joined.
+	Headers int    `json:"headers"` // block headers from the latest checkpoint or genesis to head block.
+	Bytes   int    `json:"bytes"`   // in serialized representation.
+}
+
+// Bootstrap makes client follow head block by downloading the header chain from full node.
+// The chain starts at the latest checkpoint on it if exists, otherwise at genesis.
+// Client joins without TXOs, so branch updates of TXOs it receives later come with
+// blocks like other clients.
+func (c *Client) Bootstrap(head [32]byte) BootstrapCost {
+	cost := BootstrapCost{Height: Blocks[head].Height}
+	start := uint64(0)
+	if checkpoint := LatestCheckpoint(); checkpoint != nil && checkpoint.Height <= cost.Height {
+		start = checkpoint.Height
+	}
+	for blockHash := head; ; blockHash = Blocks[blockHash].Parent {
+		c.Blocks[blockHash] = true
+		cost.Headers++
+		if Blocks[blockHash].Height <= start {
+			break
+		}
+	}
+	cost.Bytes = cost.Headers * (SerializedSize.BlockHash + SerializedSize.Header)
+	c.HeadBlock = head
+	c.Unused[head] = map[types.Uint256]*TXO{}
+	return cost
+}
+
+// AbandonedTXOs returns unused TXOs of client at its head block, which are never spent after client leaves.
+func (c *Client) AbandonedTXOs() []*TXO {
+	var txos []*TXO
+	for _, txo := range c.Unused[c.HeadBlock] {
+		txos = append(txos, txo)
+	}
+	return txos
+}
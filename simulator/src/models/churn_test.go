@@ -0,0 +1,78 @@
+package models
+
+import "testing"
+
+func TestClient_Bootstrap(t *testing.T) {
+	defer resetGlobals()()
+	clients := []*Client{NewClient(0)}
+	node := NewNode(0, clients[0])
+	deliver := func(branches map[BranchID][32]byte, newTXOs []*TXO, usedTXOs []*TXO, block *Block) [32]byte {
+		blockHash := block.Hash()
+		branchIDs := StoreBlock(block, branches)
+		for _, client := range clients {
+			client.Update(branchIDs, newTXOs, usedTXOs, blockHash)
+		}
+		return blockHash
+	}
+	genesisTXOs := []*TXO{NewTXOWithoutIndex(NullHash[0], 0, 100000)}
+	deliver(node.BuildGenesis(NullHash[0], genesisTXOs))
+	var head [32]byte
+	for height := 1; height <= 4; height++ {
+		head = deliver(node.BuildBlock(nil))
+	}
+
+	tests := []struct {
+		name       string
+		checkpoint *Checkpoint
+		want       int // headers from head block at height 4.
+	}{
+		{"from genesis", nil, 5},
+		{"from checkpoint", &Checkpoint{Height: 2}, 3},
+		{"from checkpoint at head block", &Checkpoint{Height: 4}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Checkpoints = nil
+			if tt.checkpoint != nil {
+				tt.checkpoint.BlockHash, _ = ancestorAt(head, tt.checkpoint.Height)
+				Checkpoints = []*Checkpoint{tt.checkpoint}
+			}
+			client := NewClient(1)
+			cost := client.Bootstrap(head)
+			want := BootstrapCost{Height: 4, Headers: tt.want, Bytes: tt.want * (SerializedSize.BlockHash + SerializedSize.Header)}
+			if cost != want || len(client.Blocks) != tt.want || client.HeadBlock != head {
+				t.Errorf("Client.Bootstrap() = %+v with %d headers, want %+v", cost, len(client.Blocks), want)
+			}
+		})
+	}
+	Checkpoints = nil
+
+	// client joining mid-simulation follows later blocks, receives a payment and spends it.
+	joined := NewClient(1)
+	joined.Bootstrap(head)
+	clients = append(clients, joined)
+	selector, _ := NewCoinSelector("largest-first")
+	tx, err := BuildPayment(clients[0], joined, 50000, selector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head = deliver(node.BuildBlock([]*Transaction{tx}))
+	if joined.HeadBlock != head || joined.Balance(head) != 50000 {
+		t.Fatalf("joined client follows block at height %d with balance %d, want head block and 50000",
+			Blocks[joined.HeadBlock].Height, joined.Balance(joined.HeadBlock))
+	}
+	for _, txo := range joined.Unused[head] {
+		proof, err := joined.BuildProof(txo)
+		if err != nil || proof.Root(false) != Blocks[head].Root {
+			t.Errorf("proof of TXO joined client received doesn't reach the root of head block: %v", err)
+		}
+	}
+	tx, err = BuildPayment(joined, clients[0], 1000, selector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, usedTXOs, _ := node.BuildBlock([]*Transaction{tx})
+	if len(usedTXOs) != 1 {
+		t.Error("BuildBlock() didn't include payment of joined client")
+	}
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"errors"
+	"sort"
 	"trail_simulator/simulator/src/setting"
 	"trail_simulator/simulator/src/types"
 )
@@ -16,17 +17,23 @@ type Client struct {
 	Used      map[[32]byte]map[types.Uint256]*TXO // list of used TXOs. the first keys are hash value of the block used TXO.
 	Memory    map[BranchID]map[[32]byte]bool      // update history of Merkle proof on device.
 	Archive   map[BranchID]map[[32]byte]bool      // update history of Merkle proof archived.
+	History   *History                            // data pruned after finality. nil if client doesn't keep history.
+	Rejected  int                                 // number of blocks rejected because they reorganize below checkpoint.
 }
 
 // NewClient provide new client.
 func NewClient(address uint32) *Client {
-	return &Client{Address: address,
+	client := &Client{Address: address,
 		Blocks:  map[[32]byte]bool{},
 		TXOs:    []*TXO{},
 		Unused:  map[[32]byte]map[types.Uint256]*TXO{},
 		Used:    map[[32]byte]map[types.Uint256]*TXO{},
 		Memory:  map[BranchID]map[[32]byte]bool{},
 		Archive: map[BranchID]map[[32]byte]bool{}}
+	if setting.KeepHistory {
+		client.History = NewHistory()
+	}
+	return client
 }
 
 // UnusedSize is number of unused TXOs.
@@ -65,6 +72,46 @@ func (c Client) ArchiveSize() int {
 	return size
 }
 
+// ProofBranchesSize is number of branches client tracks for Merkle proofs on device.
+func (c Client) ProofBranchesSize() int {
+	return len(c.Memory)
+}
+
+// SharedProofBranchesSize is number of proof branches of unused TXOs at head block which are shared with
+// other unused TXOs. Proofs of two TXOs share the siblings of their common ancestor and its ancestors.
+func (c Client) SharedProofBranchesSize() int {
+	var leaves []TreePosition
+	for index := range c.Unused[c.HeadBlock] {
+		leaves = append(leaves, LeafPosition(index))
+	}
+	sort.Slice(leaves, func(i, j int) bool {
+		return leaves[i].Index.Cmp(leaves[j].Index) < 0
+	})
+	// adjacent leaves have the lowest common ancestors.
+	shared := map[BranchID]bool{}
+	for i := 1; i < len(leaves); i++ {
+		ancestor := leaves[i-1].CommonAncestor(leaves[i])
+		for ; !ancestor.IsRoot(); ancestor = ancestor.Parent() {
+			id := ancestor.Sibling().BranchID()
+			if shared[id] {
+				break
+			}
+			shared[id] = true
+		}
+	}
+	return len(shared)
+}
+
+// BlocksSize is number of block hashes client recieved.
+func (c Client) BlocksSize() int {
+	return len(c.Blocks)
+}
+
+// TXOsSize is number of own TXOs client keeps.
+func (c Client) TXOsSize() int {
+	return len(c.TXOs)
+}
+
 // BuildProof returns proof of TXO at the block which client consider as head block.
 func (c Client) BuildProof(txo *TXO) (*Proof, error) {
 	proofs := [255][32]byte{}
@@ -115,7 +162,7 @@ func (c Client) Balance(blockHash [32]byte) uint64 {
 }
 
 func (c *Client) downloadBranchUpdates(from [32]byte, newBlockHash [32]byte) {
-	var branchIDs map[BranchID]bool
+	branchIDs := map[BranchID]bool{}
 	for _, txo := range c.Unused[newBlockHash] {
 		proofIDs := getProofBranchIDs(txo.Index)
 		for _, proofID := range proofIDs {
@@ -209,23 +256,24 @@ func (c *Client) archiveNotReferenceBranchUpdate(newMemory map[BranchID]map[[32]
 
 func (c *Client) downloadBlocksUntilSameHeightBlockAsCurrentHeadBlock(newHeadBlockHash [32]byte) *Block {
 	block := Blocks[newHeadBlockHash]
-	for ; block.Height > Blocks[c.HeadBlock].Height; block = Blocks[block.Parent] {
-		if _, exists := c.Blocks[block.Parent]; exists {
+	headHeight := Blocks[c.HeadBlock].Height
+	for ; block.Height > headHeight; block = Blocks[block.Parent] {
+		// client would have selected a received block higher than its head block.
+		if _, exists := c.Blocks[block.Parent]; exists && Blocks[block.Parent].Height > headHeight {
 			panic("clinet update: client selected less height block")
-		} else {
-			c.Blocks[block.Parent] = true
 		}
+		c.Blocks[block.Parent] = true
 	}
 	return block
 }
 
-func (c *Client) downloadParentBlocksNotHave(from *Block, unuseds map[types.Uint256]*TXO) (*Block, *Block, map[types.Uint256]*TXO) {
+// downloadParentBlocksNotHave walks back both chains to the fork point, and returns the fork point block and
+// TXOs unused there. Blocks on the new chain may have been received before the client switched away from it.
+func (c *Client) downloadParentBlocksNotHave(from *Block, unuseds map[types.Uint256]*TXO) (*Block, map[types.Uint256]*TXO) {
 	clientBlock := Blocks[c.HeadBlock]
 	block := from
 	for block.Parent != clientBlock.Parent {
-		if _, exists := c.Blocks[block.Parent]; exists {
-			break
-		}
+		c.Blocks[block.Parent] = true
 		txos, exists := c.Used[clientBlock.Parent]
 		if exists {
 			for _, txo := range txos {
@@ -235,20 +283,20 @@ func (c *Client) downloadParentBlocksNotHave(from *Block, unuseds map[types.Uint
 		block = Blocks[block.Parent]
 		clientBlock = Blocks[clientBlock.Parent]
 	}
-	return block, clientBlock, unuseds
+	return Blocks[block.Parent], unuseds
 }
 
-func (c *Client) updateUnuseds(newBlockHash [32]byte, blockHash [32]byte, clientBlockHash [32]byte, unuseds map[types.Uint256]*TXO) {
-	if blockHash != clientBlockHash {
-		c.Unused[newBlockHash] = c.Unused[blockHash]
-		delete(c.Unused, blockHash)
-	} else {
-		c.Unused[newBlockHash] = map[types.Uint256]*TXO{}
-		forkPoint := Blocks[blockHash]
-		for _, txo := range unuseds {
-			if !txo.Index.Larger(forkPoint.RightmostIndex) {
-				c.Unused[newBlockHash][txo.Index] = txo
-			}
+func (c *Client) updateUnuseds(newBlockHash [32]byte, forkPoint *Block, unuseds map[types.Uint256]*TXO) {
+	c.Unused[newBlockHash] = map[types.Uint256]*TXO{}
+	for _, txo := range unuseds {
+		if !txo.Index.Larger(forkPoint.RightmostIndex) {
+			c.Unused[newBlockHash][txo.Index] = txo
+		}
+	}
+	// TXOs used in the blocks on the new chain client received before.
+	for blockHash := Blocks[newBlockHash].Parent; blockHash != forkPoint.Hash(); blockHash = Blocks[blockHash].Parent {
+		for index := range c.Used[blockHash] {
+			delete(c.Unused[newBlockHash], index)
 		}
 	}
 }
@@ -256,29 +304,35 @@ func (c *Client) updateUnuseds(newBlockHash [32]byte, blockHash [32]byte, client
 // Update client's data.
 func (c *Client) Update(branchIDs map[BranchID]bool, newTXOs []*TXO, usedTXOs []*TXO, newBlockHash [32]byte) {
 	newBlock := Blocks[newBlockHash]
+	forked := false
 	if newBlock.Height != 0 && newBlock.Height <= Blocks[c.HeadBlock].Height {
 		return
 	}
+	if !OnCheckpointChain(newBlockHash) {
+		c.Rejected++
+		return
+	}
 	if _, exists := c.Blocks[newBlockHash]; exists {
 		return
 	}
 
 	if newBlock.Height != 0 && newBlock.Parent != c.HeadBlock {
 		block := c.downloadBlocksUntilSameHeightBlockAsCurrentHeadBlock(newBlockHash)
-		unuseds := c.Unused[c.HeadBlock]
 
 		// fork occurs
 		if block.Hash() != c.HeadBlock {
-			txos, exists := c.Used[c.HeadBlock]
-			if exists {
-				for _, txo := range txos {
-					unuseds[txo.Index] = txo
-				}
+			// unused TXOs at the current head block are kept for the fork.
+			unuseds := map[types.Uint256]*TXO{}
+			for index, txo := range c.Unused[c.HeadBlock] {
+				unuseds[index] = txo
 			}
-			block, clientBlock, unuseds := c.downloadParentBlocksNotHave(block, unuseds)
+			for index, txo := range c.Used[c.HeadBlock] {
+				unuseds[index] = txo
+			}
+			forkPoint, unuseds := c.downloadParentBlocksNotHave(block, unuseds)
 
-			c.updateUnuseds(newBlockHash, block.Parent, clientBlock.Parent, unuseds)
-			c.downloadBranchUpdates(block.Parent, newBlockHash)
+			c.updateUnuseds(newBlockHash, forkPoint, unuseds)
+			forked = true
 		} else {
 			// Client doesn't have blocks that are ancestors of newBLock, and are descendants of c.HeadBlock.
 			c.Unused[newBlockHash] = c.Unused[c.HeadBlock]
@@ -299,6 +353,13 @@ func (c *Client) Update(branchIDs map[BranchID]bool, newTXOs []*TXO, usedTXOs []
 
 	c.addUnuseds(newTXOs, newBlockHash)
 
+	if forked {
+		// memory may have only updates on the abandoned fork, and lack updates at or below the fork point
+		// for TXOs used on the fork. So the latest updates on the whole new chain are downloaded.
+		// Genesis's parent is NullHash[0].
+		c.downloadBranchUpdates(NullHash[0], newBlockHash)
+	}
+
 	newMemory := map[BranchID]map[[32]byte]bool{}
 	for _, txo := range c.Unused[newBlockHash] {
 		proofIDs := getProofBranchIDs(txo.Index)
@@ -317,3 +378,75 @@ func (c *Client) Update(branchIDs map[BranchID]bool, newTXOs []*TXO, usedTXOs []
 	c.Memory = newMemory
 	c.Blocks[newBlockHash] = true
 }
+
+// PruneFinalized drops used TXOs, block hashes and own TXOs which can never come back by reorganization.
+// Blocks deeper than depth from head block are considered final.
+// Pruned data is moved to History if client keeps history.
+func (c *Client) PruneFinalized(depth uint64) PruneResult {
+	var result PruneResult
+	head, exists := Blocks[c.HeadBlock]
+	if !exists || head.Height <= depth {
+		return result
+	}
+	threshold := head.Height - depth
+
+	prunedTXOs := map[*TXO]bool{}
+	for blockHash, txos := range c.Used {
+		if Blocks[blockHash].Height >= threshold {
+			continue
+		}
+		for _, txo := range txos {
+			prunedTXOs[txo] = true
+		}
+		result.Used += len(txos)
+		delete(c.Used, blockHash)
+	}
+
+	for blockHash := range c.Blocks {
+		if Blocks[blockHash].Height >= threshold {
+			continue
+		}
+		if c.History != nil {
+			c.History.Blocks = append(c.History.Blocks, blockHash)
+		}
+		result.Blocks++
+		delete(c.Blocks, blockHash)
+	}
+
+	if len(prunedTXOs) > 0 {
+		txos := []*TXO{}
+		for _, txo := range c.TXOs {
+			if prunedTXOs[txo] {
+				if c.History != nil {
+					c.History.TXOs = append(c.History.TXOs, txo)
+				}
+				result.TXOs++
+			} else {
+				txos = append(txos, txo)
+			}
+		}
+		c.TXOs = txos
+	}
+	return result
+}
+
+// DropBlocks discards data referring the blocks full nodes discarded.
+func (c *Client) DropBlocks(blockHashes map[[32]byte]bool) {
+	for blockHash := range blockHashes {
+		delete(c.Blocks, blockHash)
+		delete(c.Unused, blockHash)
+		delete(c.Used, blockHash)
+	}
+	for _, history := range []map[BranchID]map[[32]byte]bool{c.Memory, c.Archive} {
+		for branchID, updates := range history {
+			for blockHash := range updates {
+				if blockHashes[blockHash] {
+					delete(updates, blockHash)
+				}
+			}
+			if len(updates) == 0 {
+				delete(history, branchID)
+			}
+		}
+	}
+}
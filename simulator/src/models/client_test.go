@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+	"trail_simulator/simulator/src/types"
+)
+
+func TestClient_PruneFinalized(t *testing.T) {
+	defer resetGlobals()()
+	client := NewClient(0)
+	parent := NullHash[0]
+	for height := uint64(0); height <= 5; height++ {
+		block := &Block{Parent: parent, Height: height}
+		blockHash := block.Hash()
+		Blocks[blockHash] = block
+		txo := NewTXOWithoutIndex(parent, 0, 1000)
+		txo.SetIndex(types.FromUint64(height))
+		client.Blocks[blockHash] = true
+		client.Used[blockHash] = map[types.Uint256]*TXO{txo.Index: txo}
+		client.TXOs = append(client.TXOs, txo)
+		client.HeadBlock = blockHash
+		parent = blockHash
+	}
+
+	// blocks at height 0, 1 and 2 are deeper than 2 blocks from head block at height 5.
+	want := PruneResult{Used: 3, Blocks: 3, TXOs: 3}
+	if got := client.PruneFinalized(2); got != want {
+		t.Errorf("Client.PruneFinalized() = %+v, want %+v", got, want)
+	}
+	for blockHash, block := range Blocks {
+		if block.Height < 3 {
+			continue
+		}
+		if !client.Blocks[blockHash] {
+			t.Errorf("block at height %d is pruned", block.Height)
+		}
+		if _, exists := client.Used[blockHash]; !exists {
+			t.Errorf("used TXOs of block at height %d are pruned", block.Height)
+		}
+	}
+}
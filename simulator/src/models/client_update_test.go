@@ -0,0 +1,359 @@
+package models
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"trail_simulator/simulator/src/types"
+)
+
+var saveFixtures = flag.Bool("save-fixtures", false, "save minimized failing cases of Client.Update to testdata")
+
+const fixtureDir = "testdata/client_update"
+
+// deliveryFixture is a block tree and blocks delivered to a client.
+type deliveryFixture struct {
+	Seed       int64  `json:"seed"`                  // seed of random block tree.
+	Clients    int    `json:"clients"`               // number of clients in genesis.
+	Blocks     int    `json:"blocks"`                // number of blocks except genesis.
+	Client     uint32 `json:"client"`                // address of client under test.
+	Deliveries []int  `json:"deliveries"`            // blocks in order of creation delivered to client. 0 is genesis.
+	PruneDepth uint64 `json:"prune_depth,omitempty"` // depth of branch log pruning at client's head after each delivery. 0 disables it.
+}
+
+// treeBlock is a block of random block tree and data client receives with it.
+type treeBlock struct {
+	hash      [32]byte
+	branchIDs map[BranchID]bool
+	newTXOs   []*TXO
+	usedTXOs  []*TXO
+	unspent   map[types.Uint256]*TXO // all unspent TXOs at the block.
+}
+
+// resetGlobals clears blocks, branches and checkpoints of full nodes and returns function restoring them.
+func resetGlobals() func() {
+	blocks, branches, checkpoints := Blocks, Branches, Checkpoints
+	Blocks = map[[32]byte]*Block{}
+	Branches = map[BranchID]*Branch{}
+	Checkpoints = nil
+	return func() {
+		Blocks, Branches, Checkpoints = blocks, branches, checkpoints
+	}
+}
+
+// referenceProof returns proof of TXO at the block from reference tree.
+func referenceProof(reference *ReferenceTree, blockHash [32]byte, txo *TXO) *Proof {
+	return referenceProofs(reference, blockHash, []*TXO{txo})[0]
+}
+
+// referenceProofs returns proofs of TXOs at the block from reference tree, which is computed once.
+func referenceProofs(reference *ReferenceTree, blockHash [32]byte, txos []*TXO) []*Proof {
+	nodes := reference.nodes(blockHash)
+	var proofs []*Proof
+	for _, txo := range txos {
+		hashes := [255][32]byte{}
+		for h, siblingID := range getProofBranchIDs(txo.Index) {
+			hashes[h] = nodeHash(nodes, h, siblingID.Index())
+		}
+		proofs = append(proofs, NewProof(txo, hashes))
+	}
+	return proofs
+}
+
+// sortedTXOs returns TXOs in ascending order of index.
+func sortedTXOs(txos map[types.Uint256]*TXO) []*TXO {
+	var sorted []*TXO
+	for _, txo := range txos {
+		sorted = append(sorted, txo)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[j].Index.Larger(sorted[i].Index)
+	})
+	return sorted
+}
+
+// buildTree builds genesis and blocks whose parents are random blocks built before.
+// In each block, clients owning TXOs at the parent block merge all of them and pay to random clients.
+func buildTree(seed int64, numberOfClient int, numberOfBlock int) []*treeBlock {
+	Blocks = map[[32]byte]*Block{}
+	Branches = map[BranchID]*Branch{}
+	Checkpoints = nil
+	r := rand.New(rand.NewSource(seed))
+	reference := NewReferenceTree()
+	node := NewNode(0, NewClient(uint32(numberOfClient)))
+
+	var tree []*treeBlock
+	store := func(branches map[BranchID][32]byte, newTXOs []*TXO, usedTXOs []*TXO, block *Block, parent *treeBlock) {
+		b := &treeBlock{hash: block.Hash(), newTXOs: newTXOs, usedTXOs: usedTXOs, unspent: map[types.Uint256]*TXO{}}
+		b.branchIDs = StoreBlock(block, branches)
+		reference.AddBlock(b.hash, newTXOs, usedTXOs)
+		if parent != nil {
+			for index, txo := range parent.unspent {
+				b.unspent[index] = txo
+			}
+		}
+		for _, txo := range usedTXOs {
+			delete(b.unspent, txo.Index)
+		}
+		for _, txo := range newTXOs {
+			b.unspent[txo.Index] = txo
+		}
+		tree = append(tree, b)
+	}
+
+	var genesisTXOs []*TXO
+	for id := 0; id < numberOfClient; id++ {
+		genesisTXOs = append(genesisTXOs, NewTXOWithoutIndex(NullHash[0], uint32(id), 1000000))
+	}
+	branches, newTXOs, usedTXOs, block := node.BuildGenesis(NullHash[0], genesisTXOs)
+	store(branches, newTXOs, usedTXOs, block, nil)
+
+	for i := 0; i < numberOfBlock; i++ {
+		parent := tree[r.Intn(len(tree))]
+		owned := map[uint32][]*TXO{}
+		for _, txo := range sortedTXOs(parent.unspent) {
+			owned[txo.OwnerAddress] = append(owned[txo.OwnerAddress], txo)
+		}
+		var txs []*Transaction
+		for address := uint32(0); address < uint32(numberOfClient); address++ {
+			if len(owned[address]) == 0 || r.Intn(2) == 0 {
+				continue
+			}
+			tx := &Transaction{BlockHash: parent.hash}
+			total := uint64(0)
+			for _, txo := range owned[address] {
+				tx.Inputs = append(tx.Inputs, referenceProof(reference, parent.hash, txo))
+				total += txo.Balance
+			}
+			fee := RequiredFee(len(tx.Inputs), 1)
+			if total <= fee {
+				continue
+			}
+			tx.Outputs = []*TXO{NewTXOWithoutIndex(parent.hash, uint32(r.Intn(numberOfClient)), total-fee)}
+			txs = append(txs, tx)
+		}
+		node.Client.HeadBlock = parent.hash
+		branches, newTXOs, usedTXOs, block := node.BuildBlock(txs)
+		store(branches, newTXOs, usedTXOs, block, parent)
+	}
+	return tree
+}
+
+// replay delivers blocks of fixture to a new client and compares its data at the head block it chose
+// with the ledger client can know. Client can't know TXOs created or spent in blocks it doesn't receive
+// or ignores, so they are excluded. replay returns error describing the first difference.
+func replay(f deliveryFixture) (err error) {
+	tree := buildTree(f.Seed, f.Clients, f.Blocks)
+	client := NewClient(f.Client)
+	delivered := map[[32]byte]bool{}
+	head := tree[0].hash
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	for _, i := range f.Deliveries {
+		b := tree[i]
+		previous := client.HeadBlock
+		client.Update(b.branchIDs, b.newTXOs, b.usedTXOs, b.hash)
+		// client ignores blocks not higher than its head block.
+		if client.HeadBlock != b.hash {
+			continue
+		}
+		// client forgets TXOs created in the blocks above the fork point when it switches forks.
+		if i != 0 && !onChain(previous, b.hash) {
+			forkPoint := b.hash
+			for !onChain(forkPoint, previous) {
+				forkPoint = Blocks[forkPoint].Parent
+			}
+			for blockHash := range delivered {
+				if Blocks[blockHash].Height > Blocks[forkPoint].Height {
+					delete(delivered, blockHash)
+				}
+			}
+		}
+		delivered[b.hash] = true
+		if Blocks[b.hash].Height > Blocks[head].Height {
+			head = b.hash
+		}
+		if f.PruneDepth > 0 {
+			PruneBranches(client.HeadBlock, f.PruneDepth)
+		}
+	}
+	if client.HeadBlock != head {
+		return fmt.Errorf("head block at height %d, want %d", Blocks[client.HeadBlock].Height, Blocks[head].Height)
+	}
+
+	var headBlock *treeBlock
+	for _, b := range tree {
+		if b.hash == head {
+			headBlock = b
+		}
+	}
+	// TXOs created and spent in blocks client didn't receive on head block's chain.
+	unknown := map[types.Uint256]bool{}
+	for blockHash := head; ; blockHash = Blocks[blockHash].Parent {
+		if !delivered[blockHash] {
+			for _, b := range tree {
+				if b.hash != blockHash {
+					continue
+				}
+				for _, txo := range append(append([]*TXO{}, b.newTXOs...), b.usedTXOs...) {
+					unknown[txo.Index] = true
+				}
+			}
+		}
+		if Blocks[blockHash].Height == 0 {
+			break
+		}
+	}
+
+	unused := client.Unused[head]
+	for index, txo := range headBlock.unspent {
+		if txo.OwnerAddress != f.Client || unknown[index] {
+			continue
+		}
+		if _, exists := unused[index]; !exists {
+			return fmt.Errorf("unspent TXO %s is not in Unused", index)
+		}
+	}
+	for index, txo := range unused {
+		if unknown[index] {
+			continue
+		}
+		if _, exists := headBlock.unspent[index]; !exists {
+			return fmt.Errorf("TXO %s in Unused is not unspent", index)
+		}
+		proof, err := client.BuildProof(txo)
+		if err != nil {
+			return fmt.Errorf("proof of TXO %s: %v", index, err)
+		}
+		if proof.Root(false) != Blocks[head].Root {
+			return fmt.Errorf("proof of TXO %s doesn't verify", index)
+		}
+	}
+	return nil
+}
+
+// onChain reports whether the block is the head block or its ancestor.
+func onChain(blockHash [32]byte, head [32]byte) bool {
+	for ; ; head = Blocks[head].Parent {
+		if head == blockHash {
+			return true
+		}
+		if Blocks[head].Height == 0 {
+			return false
+		}
+	}
+}
+
+// minimize removes deliveries from failing fixture as long as it still fails.
+func minimize(f deliveryFixture) deliveryFixture {
+	for i := len(f.Deliveries) - 1; i > 0; i-- {
+		candidate := f
+		candidate.Deliveries = append(append([]int{}, f.Deliveries[:i]...), f.Deliveries[i+1:]...)
+		if replay(candidate) != nil {
+			f = candidate
+		}
+	}
+	return f
+}
+
+func loadFixtures(t *testing.T) map[string]deliveryFixture {
+	fixtures := map[string]deliveryFixture{}
+	paths, _ := filepath.Glob(filepath.Join(fixtureDir, "*.json"))
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var f deliveryFixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		fixtures[filepath.Base(path)] = f
+	}
+	return fixtures
+}
+
+func TestClient_Update_Fixtures(t *testing.T) {
+	defer resetGlobals()()
+	for name, f := range loadFixtures(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := replay(f); err != nil {
+				t.Errorf("replay() = %v", err)
+			}
+		})
+	}
+}
+
+func TestClient_Update_RandomDelivery(t *testing.T) {
+	defer resetGlobals()()
+	tests := []struct {
+		name       string
+		clients    int
+		blocks     int
+		pruneDepth uint64
+		cases      int
+	}{
+		{
+			name:    "chain of few clients",
+			clients: 3,
+			blocks:  6,
+			cases:   30,
+		},
+		{
+			name:    "bushy tree",
+			clients: 6,
+			blocks:  12,
+			cases:   30,
+		},
+		{
+			name:       "bushy tree with branch log pruning",
+			clients:    6,
+			blocks:     16,
+			pruneDepth: 2,
+			cases:      30,
+		},
+		{
+			name:       "long tree with branch log pruning",
+			clients:    4,
+			blocks:     24,
+			pruneDepth: 1,
+			cases:      30,
+		},
+	}
+	r := rand.New(rand.NewSource(1))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < tt.cases; i++ {
+				f := deliveryFixture{Seed: r.Int63(), Clients: tt.clients, Blocks: tt.blocks, Client: uint32(r.Intn(tt.clients)), PruneDepth: tt.pruneDepth}
+				f.Deliveries = []int{0}
+				for _, b := range r.Perm(tt.blocks) {
+					if r.Intn(3) > 0 {
+						f.Deliveries = append(f.Deliveries, b+1)
+					}
+				}
+				if replay(f) == nil {
+					continue
+				}
+				f = minimize(f)
+				data, _ := json.MarshalIndent(f, "", "  ")
+				t.Errorf("replay() = %v\n%s", replay(f), data)
+				if *saveFixtures {
+					os.MkdirAll(fixtureDir, 0755)
+					path := filepath.Join(fixtureDir, fmt.Sprintf("seed_%d.json", f.Seed))
+					if err := ioutil.WriteFile(path, append(data, '\n'), 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package models
 
+import "sort"
+
 // Branches is all of update history.
 var Branches map[BranchID]*Branch
 
@@ -11,6 +13,122 @@ func init() {
 	Blocks = map[[32]byte]*Block{}
 }
 
+// StoreBlock stores block and its branch hashes as full node.
+// StoreBlock returns ids of branches included in the block.
+func StoreBlock(block *Block, branches map[BranchID][32]byte) map[BranchID]bool {
+	blockHash := block.Hash()
+	Blocks[blockHash] = block
+	branchIDs := map[BranchID]bool{}
+	for branchID, hash := range branches {
+		if _, exists := Branches[branchID]; exists {
+			if preHash, exists := Branches[branchID].Log[block.Parent]; !exists || preHash != hash {
+				Branches[branchID].AddUpdate(blockHash, hash)
+			}
+		} else {
+			Branches[branchID] = NewBranch(branchID, blockHash, hash)
+		}
+		branchIDs[branchID] = true
+	}
+	return branchIDs
+}
+
+// BranchLogEntries is number of branch updates full node stores.
+func BranchLogEntries() int {
+	entries := 0
+	for _, branch := range Branches {
+		entries += len(branch.Log)
+	}
+	return entries
+}
+
+// PruneBranches discards branch updates which no block above threshold refers.
+// Threshold is the height depth blocks before head block.
+// An update is discarded if a later update on head block's chain is also at or below threshold,
+// or the update belongs to a fork abandoned at or below threshold.
+// PruneBranches returns number of discarded updates.
+func PruneBranches(head [32]byte, depth uint64) int {
+	headBlock := Blocks[head]
+	if headBlock.Height <= depth {
+		return 0
+	}
+	threshold := headBlock.Height - depth
+
+	canonical := map[[32]byte]bool{}
+	for blockHash := head; ; blockHash = Blocks[blockHash].Parent {
+		canonical[blockHash] = true
+		if Blocks[blockHash].Height == 0 {
+			break
+		}
+	}
+
+	// blocks referred from the tips of forks above threshold, and the heights where
+	// those forks branch off head block's chain at or below threshold.
+	live := map[[32]byte]bool{}
+	referenceHeights := map[uint64]bool{threshold: true}
+	for blockHash, block := range Blocks {
+		if block.Height <= threshold || canonical[blockHash] {
+			continue
+		}
+		b := blockHash
+		for ; !live[b] && !canonical[b]; b = Blocks[b].Parent {
+			live[b] = true
+		}
+		if canonical[b] && Blocks[b].Height <= threshold {
+			referenceHeights[Blocks[b].Height] = true
+		}
+	}
+
+	pruned := 0
+	for branchID, branch := range Branches {
+		// heights of updates on head block's chain, in ascending order.
+		var heights []uint64
+		byHeight := map[uint64][32]byte{}
+		for blockHash := range branch.Log {
+			if h := Blocks[blockHash].Height; canonical[blockHash] && h <= threshold {
+				heights = append(heights, h)
+				byHeight[h] = blockHash
+			}
+		}
+		sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+		// the latest updates on head block's chain at each reference height.
+		keep := map[[32]byte]bool{}
+		for height := range referenceHeights {
+			if i := sort.Search(len(heights), func(i int) bool { return heights[i] > height }); i > 0 {
+				keep[byHeight[heights[i-1]]] = true
+			}
+		}
+		for blockHash := range branch.Log {
+			if Blocks[blockHash].Height > threshold || live[blockHash] || keep[blockHash] {
+				continue
+			}
+			delete(branch.Log, blockHash)
+			pruned++
+		}
+		if len(branch.Log) == 0 {
+			delete(Branches, branchID)
+		}
+	}
+	return pruned
+}
+
+// recentBlocks returns hash values of blocks from head block to the child of the block,
+// if the block is head block or one of its ancestors less than limit blocks before head block.
+func recentBlocks(blockHash [32]byte, head [32]byte, limit uint64) ([][32]byte, bool) {
+	var chain [][32]byte
+	for b := head; uint64(len(chain)) < limit; {
+		if b == blockHash {
+			return chain, true
+		}
+		chain = append(chain, b)
+		block, exists := Blocks[b]
+		if !exists {
+			break
+		}
+		b = block.Parent
+	}
+	return nil, false
+}
+
 func downloadLatestUpdates(from [32]byte, to [32]byte, branchIDs map[BranchID]bool) map[BranchID][32]byte {
 	updateds := map[BranchID][32]byte{}
 
@@ -21,9 +139,8 @@ func downloadLatestUpdates(from [32]byte, to [32]byte, branchIDs map[BranchID]bo
 		}
 		for blockHash := to; blockHash != from; blockHash = Blocks[blockHash].Parent {
 			if _, exists := branch.Log[blockHash]; exists {
-				if _, exists := updateds[branchID]; !exists {
-					updateds[branchID] = blockHash
-				}
+				updateds[branchID] = blockHash
+				break
 			}
 		}
 	}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// GenesisAllocation is TXOs an address owns in genesis block.
+type GenesisAllocation struct {
+	Address  uint32   `json:"address"`
+	Balances []uint64 `json:"balances"` // balance of each TXO.
+	Count    int      `json:"count"`    // number of additional TXOs of Balance, for clients starting with many TXOs.
+	Balance  uint64   `json:"balance"`
+}
+
+// UniformGenesis returns allocations which give each client a TXO of the same balance.
+// The remainder of division goes to the first client so that balances sum up to total supply.
+func UniformGenesis(numberOfClient int, totalBalance uint64) ([]GenesisAllocation, error) {
+	if numberOfClient <= 0 {
+		return nil, errors.New("UniformGenesis: no clients")
+	}
+	var allocations []GenesisAllocation
+	for id := 0; id < numberOfClient; id++ {
+		allocations = append(allocations, GenesisAllocation{Address: uint32(id), Balances: []uint64{totalBalance / uint64(numberOfClient)}})
+	}
+	allocations[0].Balances[0] += totalBalance % uint64(numberOfClient)
+	return allocations, nil
+}
+
+// LoadGenesis reads allocations from JSON file of the form {"allocations": [...]}.
+// Addresses must be clients and appear at most once, every TXO must have balance,
+// and balances must sum up to total supply.
+func LoadGenesis(path string, numberOfClient int, totalBalance uint64) ([]GenesisAllocation, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var spec struct {
+		Allocations []GenesisAllocation `json:"allocations"`
+	}
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("LoadGenesis: %v", err)
+	}
+
+	seen := map[uint32]bool{}
+	total := uint64(0)
+	numberOfTXOs := 0
+	for _, allocation := range spec.Allocations {
+		if int(allocation.Address) >= numberOfClient {
+			return nil, fmt.Errorf("LoadGenesis: address %d is not a client", allocation.Address)
+		}
+		if seen[allocation.Address] {
+			return nil, fmt.Errorf("LoadGenesis: address %d is allocated twice", allocation.Address)
+		}
+		seen[allocation.Address] = true
+		if allocation.Count < 0 || (allocation.Count > 0 && allocation.Balance == 0) {
+			return nil, fmt.Errorf("LoadGenesis: invalid count or balance of address %d", allocation.Address)
+		}
+		for _, balance := range allocation.balances() {
+			if balance == 0 {
+				return nil, fmt.Errorf("LoadGenesis: TXO of address %d has no balance", allocation.Address)
+			}
+			if total+balance < total {
+				return nil, errors.New("LoadGenesis: total balance overflows")
+			}
+			total += balance
+			numberOfTXOs++
+		}
+	}
+	if numberOfTXOs == 0 {
+		return nil, errors.New("LoadGenesis: no TXOs")
+	}
+	if total != totalBalance {
+		return nil, fmt.Errorf("LoadGenesis: total balance %d differs from total supply %d", total, totalBalance)
+	}
+	return spec.Allocations, nil
+}
+
+// balances returns balances of all TXOs of the allocation.
+func (a GenesisAllocation) balances() []uint64 {
+	balances := append([]uint64{}, a.Balances...)
+	for i := 0; i < a.Count; i++ {
+		balances = append(balances, a.Balance)
+	}
+	return balances
+}
+
+// GenesisTXOs returns TXOs of allocations in order of allocations.
+func GenesisTXOs(allocations []GenesisAllocation, parentHash [32]byte) []*TXO {
+	var txos []*TXO
+	for _, allocation := range allocations {
+		for _, balance := range allocation.balances() {
+			txos = append(txos, NewTXOWithoutIndex(parentHash, allocation.Address, balance))
+		}
+	}
+	return txos
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUniformGenesis(t *testing.T) {
+	allocations, err := UniformGenesis(3, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []GenesisAllocation{
+		{Address: 0, Balances: []uint64{34}},
+		{Address: 1, Balances: []uint64{33}},
+		{Address: 2, Balances: []uint64{33}},
+	}
+	if !reflect.DeepEqual(allocations, want) {
+		t.Errorf("UniformGenesis() = %+v, want %+v", allocations, want)
+	}
+	if _, err := UniformGenesis(0, 100); err == nil {
+		t.Error("UniformGenesis() of no clients succeeded")
+	}
+}
+
+func TestLoadGenesis(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genesis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name    string
+		json    string
+		want    []uint64 // balances of genesis TXOs.
+		wantErr bool
+	}{
+		{
+			name: "balances and count",
+			json: `{"allocations": [{"address": 0, "balances": [10, 20]}, {"address": 2, "count": 2, "balance": 35}]}`,
+			want: []uint64{10, 20, 35, 35},
+		},
+		{
+			name:    "unknown field",
+			json:    `{"allocations": [{"address": 0, "balances": [100]}], "clients": 3}`,
+			wantErr: true,
+		},
+		{
+			name:    "address is not a client",
+			json:    `{"allocations": [{"address": 3, "balances": [100]}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "address allocated twice",
+			json:    `{"allocations": [{"address": 0, "balances": [50]}, {"address": 0, "balances": [50]}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "negative count",
+			json:    `{"allocations": [{"address": 0, "balances": [100], "count": -1, "balance": 1}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "count without balance",
+			json:    `{"allocations": [{"address": 0, "balances": [100], "count": 1}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "TXO without balance",
+			json:    `{"allocations": [{"address": 0, "balances": [100, 0]}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "total balance overflows",
+			json:    `{"allocations": [{"address": 0, "balances": [18446744073709551615, 101]}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "no TXOs",
+			json:    `{"allocations": []}`,
+			wantErr: true,
+		},
+		{
+			name:    "total balance differs from total supply",
+			json:    `{"allocations": [{"address": 0, "balances": [99]}]}`,
+			wantErr: true,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, fmt.Sprintf("genesis_%d.json", i))
+			if err := ioutil.WriteFile(path, []byte(tt.json), 0644); err != nil {
+				t.Fatal(err)
+			}
+			allocations, err := LoadGenesis(path, 3, 100)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadGenesis() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var got []uint64
+			for _, txo := range GenesisTXOs(allocations, NullHash[0]) {
+				got = append(got, txo.Balance)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("balances of genesis TXOs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if _, err := LoadGenesis(filepath.Join(dir, "missing.json"), 3, 100); err == nil {
+		t.Error("LoadGenesis() of missing file succeeded")
+	}
+}
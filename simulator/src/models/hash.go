@@ -0,0 +1,33 @@
+package models
+
+import (
+	"encoding/hex"
+	"errors"
+)
+
+// shortHashBytes is the number of leading bytes of a hash shown in reports.
+const shortHashBytes = 8
+
+// HashString returns hash of a block, branch or TXO in hex.
+func HashString(hash [32]byte) string {
+	return hex.EncodeToString(hash[:])
+}
+
+// ShortHashString returns hex of leading bytes of hash, which is enough to reference a block in reports.
+func ShortHashString(hash [32]byte) string {
+	return hex.EncodeToString(hash[:shortHashBytes])
+}
+
+// ParseHash parses hash in hex HashString returns.
+func ParseHash(s string) ([32]byte, error) {
+	var hash [32]byte
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return hash, errors.New("ParseHash: " + err.Error())
+	}
+	if len(b) != len(hash) {
+		return hash, errors.New("ParseHash: hash must be 32 bytes " + s)
+	}
+	copy(hash[:], b)
+	return hash, nil
+}
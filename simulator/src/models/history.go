@@ -0,0 +1,32 @@
+package models
+
+// History stores data client pruned from memory after finality.
+type History struct {
+	TXOs   []*TXO     // own TXOs used at finalized blocks.
+	Blocks [][32]byte // hash values of finalized blocks.
+}
+
+// NewHistory provides new history store.
+func NewHistory() *History {
+	return &History{TXOs: []*TXO{}, Blocks: [][32]byte{}}
+}
+
+// Bytes returns byte size of history store in the size model.
+func (h *History) Bytes(m SizeModel) int {
+	if h == nil {
+		return 0
+	}
+	return len(h.TXOs)*(m.Pointer+m.TXO) + len(h.Blocks)*m.BlockHash
+}
+
+// PruneResult is number of entries client pruned from memory.
+type PruneResult struct {
+	Used   int `json:"used"`
+	Blocks int `json:"blocks"`
+	TXOs   int `json:"txos"`
+}
+
+// Add returns sum of pruned entries.
+func (r PruneResult) Add(a PruneResult) PruneResult {
+	return PruneResult{r.Used + a.Used, r.Blocks + a.Blocks, r.TXOs + a.TXOs}
+}
@@ -0,0 +1,184 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"trail_simulator/simulator/src/types"
+)
+
+// Invariants the checker verifies.
+const (
+	SupplyInvariant        = "supply"          // unspent balance at head block equals total supply.
+	UnusedAndUsedInvariant = "unused_and_used" // no TXO is both unused and used at client's head block.
+	UnusedProofInvariant   = "unused_proof"    // client proves its unused TXO against root of its head block.
+	UsedProofInvariant     = "used_proof"      // used TXO verifies as used against root of client's head block.
+)
+
+// Violation is a broken invariant found by checker.
+type Violation struct {
+	Invariant string  `json:"invariant"`
+	Address   *uint32 `json:"address,omitempty"` // owner of the TXO, nil if the invariant is about the ledger.
+	Index     string  `json:"index,omitempty"`   // leaf index of the TXO in hex.
+	Detail    string  `json:"detail"`
+}
+
+type ledgerDiff struct {
+	newTXOs  []*TXO
+	usedTXOs []*TXO
+}
+
+// InvariantChecker verifies the ledger and clients' data after every block.
+// It keeps TXOs each block adds and spends to know unspent TXOs at any block.
+type InvariantChecker struct {
+	diffs   map[[32]byte]ledgerDiff
+	head    [32]byte
+	unspent map[types.Uint256]uint64 // balances of unspent TXOs at head.
+}
+
+// NewInvariantChecker provides checker which knows no blocks.
+func NewInvariantChecker() *InvariantChecker {
+	return &InvariantChecker{
+		diffs:   map[[32]byte]ledgerDiff{},
+		unspent: map[types.Uint256]uint64{}}
+}
+
+// AddBlock records TXOs the block adds and spends.
+func (ic *InvariantChecker) AddBlock(blockHash [32]byte, newTXOs []*TXO, usedTXOs []*TXO) {
+	ic.diffs[blockHash] = ledgerDiff{newTXOs, usedTXOs}
+}
+
+func (ic *InvariantChecker) apply(blockHash [32]byte) {
+	diff := ic.diffs[blockHash]
+	for _, txo := range diff.usedTXOs {
+		delete(ic.unspent, txo.Index)
+	}
+	for _, txo := range diff.newTXOs {
+		ic.unspent[txo.Index] = txo.Balance
+	}
+	ic.head = blockHash
+}
+
+// moveTo makes unspent TXOs those at the block.
+// Child block of current head is applied incrementally, otherwise the chain is replayed from genesis.
+func (ic *InvariantChecker) moveTo(blockHash [32]byte) {
+	if blockHash == ic.head && len(ic.unspent) > 0 {
+		return
+	}
+	if block := Blocks[blockHash]; block.Height > 0 && block.Parent == ic.head {
+		ic.apply(blockHash)
+		return
+	}
+	var chain [][32]byte
+	for b := blockHash; ; b = Blocks[b].Parent {
+		chain = append(chain, b)
+		if Blocks[b].Height == 0 {
+			break
+		}
+	}
+	ic.unspent = map[types.Uint256]uint64{}
+	for i := len(chain) - 1; i >= 0; i-- {
+		ic.apply(chain[i])
+	}
+}
+
+// Supply returns total balance of unspent TXOs at the block.
+func (ic *InvariantChecker) Supply(blockHash [32]byte) uint64 {
+	ic.moveTo(blockHash)
+	supply := uint64(0)
+	for _, balance := range ic.unspent {
+		supply += balance
+	}
+	return supply
+}
+
+// Check verifies invariants at head block and of clients at their head blocks.
+// It returns violations found, which are empty if the ledger and clients are consistent.
+func (ic *InvariantChecker) Check(head [32]byte, clients []*Client, totalSupply uint64) []Violation {
+	var violations []Violation
+	if supply := ic.Supply(head); supply != totalSupply {
+		violations = append(violations, Violation{
+			Invariant: SupplyInvariant,
+			Detail:    fmt.Sprintf("unspent balance %d differs from total supply %d", supply, totalSupply)})
+	}
+
+	// branch hashes at each head block looked up.
+	cache := map[[32]byte]map[BranchID][32]byte{}
+	for _, client := range clients {
+		address := client.Address
+		violation := func(invariant string, txo *TXO, detail string) Violation {
+			return Violation{invariant, &address, txo.Index.String(), detail}
+		}
+		root := Blocks[client.HeadBlock].Root
+		unused := client.Unused[client.HeadBlock]
+		for _, txo := range unused {
+			proof, err := client.BuildProof(txo)
+			if err != nil {
+				violations = append(violations, violation(UnusedProofInvariant, txo, err.Error()))
+			} else if proof.Root(false) != root {
+				violations = append(violations, violation(UnusedProofInvariant, txo, "root differs"))
+			}
+		}
+
+		if _, exists := cache[client.HeadBlock]; !exists {
+			cache[client.HeadBlock] = map[BranchID][32]byte{}
+		}
+		for blockHash, txos := range client.Used {
+			block, exists := Blocks[blockHash]
+			if !exists {
+				continue
+			}
+			if ancestor, exists := ancestorAt(client.HeadBlock, block.Height); !exists || ancestor != blockHash {
+				continue
+			}
+			for index, txo := range txos {
+				if _, exists := unused[index]; exists {
+					violations = append(violations, violation(UnusedAndUsedInvariant, txo, "unused at head block"))
+				}
+				if err := verifyUsed(txo, client.HeadBlock, cache[client.HeadBlock]); err != nil {
+					violations = append(violations, violation(UsedProofInvariant, txo, err.Error()))
+				}
+			}
+		}
+	}
+	return violations
+}
+
+// branchHash returns hash of the branch at the block from branch updates full node stores.
+// cache holds branch hashes at the block already looked up.
+func branchHash(branchID BranchID, blockHash [32]byte, cache map[BranchID][32]byte) ([32]byte, error) {
+	if hash, exists := cache[branchID]; exists {
+		return hash, nil
+	}
+	branch, exists := Branches[branchID]
+	if !exists {
+		return [32]byte{}, fmt.Errorf("branchHash: no branch data of %s", branchID)
+	}
+	for b := blockHash; ; {
+		if hash, exists := branch.Log[b]; exists {
+			cache[branchID] = hash
+			return hash, nil
+		}
+		block, exists := Blocks[b]
+		if !exists || block.Height == 0 {
+			return [32]byte{}, fmt.Errorf("branchHash: no update of branch %s", branchID)
+		}
+		b = block.Parent
+	}
+}
+
+// verifyUsed verifies used TXO against root of the block by Proof.Root(true) with proof built from
+// branch updates full node stores.
+func verifyUsed(txo *TXO, blockHash [32]byte, cache map[BranchID][32]byte) error {
+	proofs := [255][32]byte{}
+	for h, branchID := range getProofBranchIDs(txo.Index) {
+		hash, err := branchHash(branchID, blockHash, cache)
+		if err != nil {
+			return err
+		}
+		proofs[h] = hash
+	}
+	if NewProof(txo, proofs).Root(true) != Blocks[blockHash].Root {
+		return errors.New("verifyUsed: root differs")
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"testing"
+	"trail_simulator/simulator/src/types"
+)
+
+func TestInvariantChecker_Check(t *testing.T) {
+	defer resetGlobals()()
+	clients := []*Client{NewClient(0), NewClient(1)}
+	node := NewNode(0, clients[0])
+	checker := NewInvariantChecker()
+	deliver := func(branches map[BranchID][32]byte, newTXOs []*TXO, usedTXOs []*TXO, block *Block) [32]byte {
+		blockHash := block.Hash()
+		branchIDs := StoreBlock(block, branches)
+		checker.AddBlock(blockHash, newTXOs, usedTXOs)
+		for _, client := range clients {
+			client.Update(branchIDs, newTXOs, usedTXOs, blockHash)
+		}
+		return blockHash
+	}
+	genesisTXOs := []*TXO{NewTXOWithoutIndex(NullHash[0], 0, 100000), NewTXOWithoutIndex(NullHash[0], 1, 100000)}
+	deliver(node.BuildGenesis(NullHash[0], genesisTXOs))
+	selector, _ := NewCoinSelector("largest-first")
+	tx, err := BuildPayment(clients[0], clients[1], 1000, selector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head := deliver(node.BuildBlock([]*Transaction{tx}))
+	spent := tx.Inputs[0].TXO
+	var unspent *TXO // TXO of client 1 at head block.
+	for _, txo := range clients[1].Unused[head] {
+		unspent = txo
+	}
+	if len(clients[0].Used[head]) != 1 || unspent == nil {
+		t.Fatal("clients don't know TXOs of the payment")
+	}
+	if violations := checker.Check(head, clients, 200000); len(violations) != 0 {
+		t.Fatalf("InvariantChecker.Check() = %+v, want no violations", violations)
+	}
+
+	tests := []struct {
+		name        string
+		totalSupply uint64
+		corrupt     func() (undo func())
+		want        string
+	}{
+		{
+			name:        "supply differs",
+			totalSupply: 200001,
+			corrupt:     func() func() { return func() {} },
+			want:        SupplyInvariant,
+		},
+		{
+			name:        "spent TXO is unused",
+			totalSupply: 200000,
+			corrupt: func() func() {
+				clients[0].Unused[head][spent.Index] = spent
+				return func() { delete(clients[0].Unused[head], spent.Index) }
+			},
+			want: UnusedAndUsedInvariant,
+		},
+		{
+			name:        "unused TXO isn't in the tree",
+			totalSupply: 200000,
+			corrupt: func() func() {
+				txo := NewTXOWithoutIndex(head, 0, 1)
+				txo.SetIndex(types.FromUint64(100))
+				clients[0].Unused[head][txo.Index] = txo
+				return func() { delete(clients[0].Unused[head], txo.Index) }
+			},
+			want: UnusedProofInvariant,
+		},
+		{
+			name:        "unspent TXO is used",
+			totalSupply: 200000,
+			corrupt: func() func() {
+				clients[0].Used[head][unspent.Index] = unspent
+				return func() { delete(clients[0].Used[head], unspent.Index) }
+			},
+			want: UsedProofInvariant,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			undo := tt.corrupt()
+			defer undo()
+			violations := checker.Check(head, clients, tt.totalSupply)
+			found := false
+			for _, violation := range violations {
+				found = found || violation.Invariant == tt.want
+			}
+			if !found {
+				t.Errorf("InvariantChecker.Check() = %+v, want violation of %s", violations, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package models
+
+import (
+	"container/heap"
+	"sort"
+	"trail_simulator/simulator/src/setting"
+	"trail_simulator/simulator/src/types"
+)
+
+// Mempool holds transactions a node recieved and has not included in blocks yet.
+type Mempool struct {
+	pending  map[*Transaction]*pendingTx
+	spending map[types.Uint256]*Transaction // key is index of input TXO of pending transaction.
+	eviction evictionHeap
+	arrivals uint64 // number of transactions added so far.
+	Stats    MempoolStats
+}
+
+// pendingTx is a pending transaction with the order it arrived in.
+type pendingTx struct {
+	tx       *Transaction
+	feeRate  float64
+	height   uint64 // height of head block when the transaction arrived.
+	sequence uint64 // arrival order among transactions added to mempool.
+	index    int    // position in eviction heap.
+}
+
+// precedes returns whether p comes before q in Sorted order,
+// that is p has higher fee rate or the same fee rate and arrived earlier.
+func (p *pendingTx) precedes(q *pendingTx) bool {
+	if p.feeRate != q.feeRate {
+		return p.feeRate > q.feeRate
+	}
+	return p.sequence < q.sequence
+}
+
+// evictionHeap is a heap of pending transactions whose top is the last one in Sorted order,
+// the transaction evicted first when mempool is full.
+type evictionHeap []*pendingTx
+
+func (h evictionHeap) Len() int           { return len(h) }
+func (h evictionHeap) Less(i, j int) bool { return h[j].precedes(h[i]) }
+func (h evictionHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *evictionHeap) Push(x interface{}) {
+	p := x.(*pendingTx)
+	p.index = len(*h)
+	*h = append(*h, p)
+}
+
+func (h *evictionHeap) Pop() interface{} {
+	old := *h
+	p := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return p
+}
+
+// MempoolStats is number of transactions by how they entered and left mempool.
+type MempoolStats struct {
+	Added        int   `json:"added"`
+	Rejected     int   `json:"rejected"` // conflicting with pending transaction, stale or mempool is full.
+	Included     int   `json:"included"`
+	EvictedSpent int   `json:"evicted_spent"` // some inputs were spent by other transaction.
+	EvictedStale int   `json:"evicted_stale"` // proofs are no longer valid.
+	EvictedFull  int   `json:"evicted_full"`  // replaced by transaction with higher fee rate.
+	Latencies    []int `json:"-"`             // number of blocks from arrival to inclusion of included transactions.
+}
+
+// NewMempool provides empty mempool.
+func NewMempool() *Mempool {
+	return &Mempool{
+		pending:  map[*Transaction]*pendingTx{},
+		spending: map[types.Uint256]*Transaction{}}
+}
+
+// Size is number of pending transactions.
+func (m *Mempool) Size() int {
+	return len(m.pending)
+}
+
+// isStale returns whether proofs of the transaction can't be accepted in the next block of head block.
+func (m *Mempool) isStale(tx *Transaction, head [32]byte) bool {
+	_, recent := recentBlocks(tx.BlockHash, head, setting.ProofValidityBlocks)
+	return !recent
+}
+
+// Add adds transaction recieved when node follows head block.
+// If mempool is full, the pending transaction with the lowest fee rate is evicted
+// when it is lower than the fee rate of the new transaction.
+// Add returns false if the transaction is rejected.
+func (m *Mempool) Add(tx *Transaction, head [32]byte) bool {
+	if m.isStale(tx, head) || len(tx.Inputs) == 0 || !tx.MultiproofMatchesInputs() {
+		m.Stats.Rejected++
+		return false
+	}
+	for _, proof := range tx.Inputs {
+		if _, exists := m.spending[proof.TXO.Index]; exists {
+			m.Stats.Rejected++
+			return false
+		}
+	}
+	feeRate := tx.FeeRate()
+	if len(m.pending) >= setting.MaxMempoolSize {
+		lowest := m.eviction[0]
+		if lowest.feeRate >= feeRate {
+			m.Stats.Rejected++
+			return false
+		}
+		m.remove(lowest.tx)
+		m.Stats.EvictedFull++
+	}
+	p := &pendingTx{tx: tx, feeRate: feeRate, height: Blocks[head].Height, sequence: m.arrivals}
+	m.arrivals++
+	m.pending[tx] = p
+	heap.Push(&m.eviction, p)
+	for _, proof := range tx.Inputs {
+		m.spending[proof.TXO.Index] = tx
+	}
+	m.Stats.Added++
+	return true
+}
+
+func (m *Mempool) remove(tx *Transaction) {
+	heap.Remove(&m.eviction, m.pending[tx].index)
+	delete(m.pending, tx)
+	for _, proof := range tx.Inputs {
+		if m.spending[proof.TXO.Index] == tx {
+			delete(m.spending, proof.TXO.Index)
+		}
+	}
+}
+
+// Sorted returns pending transactions in descending order of fee rate.
+// Transactions with the same fee rate are sorted in order of arrival.
+func (m *Mempool) Sorted() []*Transaction {
+	pending := make([]*pendingTx, 0, len(m.pending))
+	for _, p := range m.pending {
+		pending = append(pending, p)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].precedes(pending[j]) })
+	txs := make([]*Transaction, len(pending))
+	for i, p := range pending {
+		txs[i] = p.tx
+	}
+	return txs
+}
+
+// Update removes transactions which are included in or conflict with the new head block,
+// and transactions whose proofs become stale.
+func (m *Mempool) Update(usedTXOs []*TXO, head [32]byte) {
+	used := map[types.Uint256]bool{}
+	for _, txo := range usedTXOs {
+		used[txo.Index] = true
+	}
+	height := Blocks[head].Height
+	for tx, p := range m.pending {
+		spent := 0
+		for _, proof := range tx.Inputs {
+			if used[proof.TXO.Index] {
+				spent++
+			}
+		}
+		switch {
+		case spent == len(tx.Inputs):
+			// Pending transactions never share inputs, so the transaction itself is included.
+			m.Stats.Included++
+			m.Stats.Latencies = append(m.Stats.Latencies, int(height-p.height))
+		case spent > 0:
+			m.Stats.EvictedSpent++
+		case m.isStale(tx, head):
+			m.Stats.EvictedStale++
+		default:
+			continue
+		}
+		m.remove(tx)
+	}
+}
+
+// TakeStats returns stats since the last call and resets them.
+func (m *Mempool) TakeStats() MempoolStats {
+	stats := m.Stats
+	m.Stats = MempoolStats{}
+	return stats
+}
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"testing"
+	"trail_simulator/simulator/src/setting"
+	"trail_simulator/simulator/src/types"
+)
+
+// mempoolChain stores genesis and returns a function extending the chain by an empty block.
+// Mempool only checks input indexes and the block of transactions, so TXOs need not be in the tree.
+func mempoolChain() (genesis [32]byte, extend func() [32]byte) {
+	node := NewNode(0, NewClient(0))
+	deliver := func(branches map[BranchID][32]byte, newTXOs []*TXO, usedTXOs []*TXO, block *Block) [32]byte {
+		blockHash := block.Hash()
+		node.Client.Update(StoreBlock(block, branches), newTXOs, usedTXOs, blockHash)
+		return blockHash
+	}
+	genesis = deliver(node.BuildGenesis(NullHash[0], []*TXO{NewTXOWithoutIndex(NullHash[0], 0, 1000)}))
+	return genesis, func() [32]byte { return deliver(node.BuildBlock(nil)) }
+}
+
+// mempoolTx returns transaction against the block spending TXOs at the indexes with the fee.
+func mempoolTx(blockHash [32]byte, fee uint64, indexes ...uint64) *Transaction {
+	tx := &Transaction{BlockHash: blockHash, Outputs: []*TXO{NewTXOWithoutIndex(blockHash, 0, 0)}}
+	for _, index := range indexes {
+		txo := NewTXOWithoutIndex(blockHash, 0, 1000000)
+		txo.SetIndex(types.FromUint64(index))
+		tx.Inputs = append(tx.Inputs, &Proof{TXO: txo})
+		tx.Outputs[0].Balance += txo.Balance
+	}
+	tx.Outputs[0].Balance -= fee
+	return tx
+}
+
+func TestMempool_Add(t *testing.T) {
+	defer resetGlobals()()
+	genesis, _ := mempoolChain()
+	m := NewMempool()
+
+	low := mempoolTx(genesis, 1000, 1)
+	high := mempoolTx(genesis, 2000, 2)
+	sameAsLow := mempoolTx(genesis, 1000, 3)
+	tests := []struct {
+		name string
+		tx   *Transaction
+		want bool
+	}{
+		{"low fee", low, true},
+		{"high fee", high, true},
+		{"same fee rate as earlier one", sameAsLow, true},
+		{"input of pending transaction", mempoolTx(genesis, 5000, 4, 2), false},
+		{"no inputs", mempoolTx(genesis, 0), false},
+		{"unknown block", mempoolTx([32]byte{1}, 5000, 5), false},
+	}
+	for _, tt := range tests {
+		if got := m.Add(tt.tx, genesis); got != tt.want {
+			t.Errorf("Mempool.Add() of %s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if m.Stats.Added != 3 || m.Stats.Rejected != 3 || m.Size() != 3 {
+		t.Errorf("Mempool has %d transactions after %d added and %d rejected, want 3, 3 and 3",
+			m.Size(), m.Stats.Added, m.Stats.Rejected)
+	}
+	want := []*Transaction{high, low, sameAsLow}
+	for i, tx := range m.Sorted() {
+		if tx != want[i] {
+			t.Errorf("Mempool.Sorted()[%d] has fee %d, want %d", i, tx.Fee(), want[i].Fee())
+		}
+	}
+}
+
+func TestMempool_Update(t *testing.T) {
+	defer resetGlobals()()
+	genesis, extend := mempoolChain()
+	m := NewMempool()
+	included := mempoolTx(genesis, 1000, 1, 2)
+	conflicting := mempoolTx(genesis, 1000, 3, 4)
+	pending := mempoolTx(genesis, 1000, 5)
+	for _, tx := range []*Transaction{included, conflicting, pending} {
+		if !m.Add(tx, genesis) {
+			t.Fatal("Mempool.Add() rejected transaction")
+		}
+	}
+
+	// transactions in the block are detected at the second block after arrival.
+	m.Update(nil, extend())
+	m.Update([]*TXO{included.Inputs[0].TXO, included.Inputs[1].TXO, conflicting.Inputs[1].TXO}, extend())
+	if m.Stats.Included != 1 || len(m.Stats.Latencies) != 1 || m.Stats.Latencies[0] != 2 {
+		t.Errorf("Mempool.Update() included %d transactions with latencies %v, want 1 with [2]",
+			m.Stats.Included, m.Stats.Latencies)
+	}
+	if m.Stats.EvictedSpent != 1 || m.Size() != 1 {
+		t.Errorf("Mempool.Update() evicted %d conflicting transactions and kept %d, want 1 and 1",
+			m.Stats.EvictedSpent, m.Size())
+	}
+
+	// proofs against genesis become stale once genesis is ProofValidityBlocks blocks before head.
+	for height := uint64(3); height < setting.ProofValidityBlocks; height++ {
+		m.Update(nil, extend())
+	}
+	if m.Size() != 1 || m.Stats.EvictedStale != 0 {
+		t.Fatal("Mempool.Update() evicted transaction against recent block")
+	}
+	m.Update(nil, extend())
+	if m.Size() != 0 || m.Stats.EvictedStale != 1 {
+		t.Errorf("Mempool.Update() evicted %d stale transactions and kept %d, want 1 and 0",
+			m.Stats.EvictedStale, m.Size())
+	}
+}
+
+func TestMempool_Add_Full(t *testing.T) {
+	defer resetGlobals()()
+	genesis, _ := mempoolChain()
+	m := NewMempool()
+	// the lowest fee rate is shared by the first and the last transactions.
+	txs := []*Transaction{mempoolTx(genesis, 1000, 0)}
+	for i := 1; i < setting.MaxMempoolSize-1; i++ {
+		txs = append(txs, mempoolTx(genesis, uint64(1000+i), uint64(i)))
+	}
+	txs = append(txs, mempoolTx(genesis, 1000, uint64(setting.MaxMempoolSize-1)))
+	for _, tx := range txs {
+		if !m.Add(tx, genesis) {
+			t.Fatal("Mempool.Add() rejected transaction before mempool is full")
+		}
+	}
+
+	next := uint64(setting.MaxMempoolSize)
+	if m.Add(mempoolTx(genesis, 1000, next), genesis) {
+		t.Error("Mempool.Add() accepted transaction not exceeding the lowest fee rate when full")
+	}
+	for i, want := range []*Transaction{txs[len(txs)-1], txs[0]} {
+		if !m.Add(mempoolTx(genesis, 5000, next+uint64(i)), genesis) {
+			t.Fatal("Mempool.Add() rejected transaction of higher fee rate when full")
+		}
+		if _, pending := m.pending[want]; pending {
+			t.Errorf("Mempool.Add() evicted other transaction than the last arrival of the lowest fee rate")
+		}
+	}
+	if m.Size() != setting.MaxMempoolSize || m.Stats.EvictedFull != 2 || m.Stats.Rejected != 1 {
+		t.Errorf("Mempool has %d transactions after %d evicted and %d rejected, want %d, 2 and 1",
+			m.Size(), m.Stats.EvictedFull, m.Stats.Rejected, setting.MaxMempoolSize)
+	}
+	sorted := m.Sorted()
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i-1].FeeRate() < sorted[i].FeeRate() {
+			t.Fatalf("Mempool.Sorted() isn't in descending order of fee rate at %d", i)
+		}
+	}
+}
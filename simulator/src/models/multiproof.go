@@ -0,0 +1,128 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"trail_simulator/simulator/src/types"
+)
+
+// Multiproof proves a set of TXOs together.
+// Siblings on the path of another TXO are computed by verifier, and siblings shared by paths are included once.
+// Positions of siblings are implied by indexes of TXOs, so only the hashes are encoded.
+type Multiproof struct {
+	TXOs     []*TXO
+	Siblings map[BranchID][32]byte
+}
+
+// NewMultiproof merges proofs against the same block into a multiproof.
+// It fails if proofs have different hashes of a branch.
+func NewMultiproof(proofs []*Proof) (*Multiproof, error) {
+	onPath := map[BranchID]bool{}
+	for _, proof := range proofs {
+		for position := LeafPosition(proof.TXO.Index); !position.IsRoot(); position = position.Parent() {
+			onPath[position.BranchID()] = true
+		}
+	}
+	m := &Multiproof{Siblings: map[BranchID][32]byte{}}
+	for _, proof := range proofs {
+		m.TXOs = append(m.TXOs, proof.TXO)
+		for position := LeafPosition(proof.TXO.Index); !position.IsRoot(); position = position.Parent() {
+			id := position.Sibling().BranchID()
+			if onPath[id] {
+				continue
+			}
+			if hash, exists := m.Siblings[id]; exists && hash != proof.Proofs[position.Height] {
+				return nil, errors.New("NewMultiproof: proofs differ at branch " + id.String())
+			}
+			m.Siblings[id] = proof.Proofs[position.Height]
+		}
+	}
+	return m, nil
+}
+
+// nodes returns hashes of the nodes on paths of TXOs and the root.
+// It fails if Siblings has a hash of a node on the paths, which must be computed from TXOs.
+func (m Multiproof) nodes(isUsed bool) (map[BranchID][32]byte, [32]byte, error) {
+	nodes := map[BranchID][32]byte{}
+	level := map[types.Uint256][32]byte{}
+	for _, txo := range m.TXOs {
+		if _, exists := level[txo.Index]; exists {
+			return nil, [32]byte{}, errors.New("Multiproof: duplicated TXO " + txo.Index.String())
+		}
+		level[txo.Index] = txo.Hash(isUsed)
+	}
+	for h := uint8(0); h < RootHeight; h++ {
+		parents := map[types.Uint256][32]byte{}
+		for index, hash := range level {
+			position := TreePosition{h, index}
+			nodes[position.BranchID()] = hash
+			parent := position.Parent()
+			if _, exists := parents[parent.Index]; exists {
+				continue
+			}
+			sibling := position.Sibling()
+			siblingHash, exists := level[sibling.Index]
+			if !exists {
+				siblingHash, exists = m.Siblings[sibling.BranchID()]
+			}
+			if !exists {
+				return nil, [32]byte{}, errors.New("Multiproof: no hash of branch " + sibling.BranchID().String())
+			}
+			if position.IsLeft() {
+				parents[parent.Index] = sha256.Sum256(append(hash[:], siblingHash[:]...))
+			} else {
+				parents[parent.Index] = sha256.Sum256(append(siblingHash[:], hash[:]...))
+			}
+		}
+		level = parents
+	}
+	for id := range m.Siblings {
+		if _, exists := nodes[id]; exists {
+			return nil, [32]byte{}, errors.New("Multiproof: branch " + id.String() + " is on path of TXOs")
+		}
+	}
+	for _, root := range level {
+		return nodes, root, nil
+	}
+	return nil, [32]byte{}, errors.New("Multiproof: no TXOs")
+}
+
+// Root returns the root calculated from TXOs and siblings.
+func (m Multiproof) Root(isUsed bool) ([32]byte, error) {
+	_, root, err := m.nodes(isUsed)
+	return root, err
+}
+
+// Proofs returns the proof of each TXO the multiproof contains.
+// Siblings on the path of another TXO are the hashes computed from TXOs.
+func (m Multiproof) Proofs() ([]*Proof, error) {
+	nodes, _, err := m.nodes(false)
+	if err != nil {
+		return nil, err
+	}
+	var proofs []*Proof
+	for _, txo := range m.TXOs {
+		hashes := [255][32]byte{}
+		for position := LeafPosition(txo.Index); !position.IsRoot(); position = position.Parent() {
+			id := position.Sibling().BranchID()
+			if hash, exists := nodes[id]; exists {
+				hashes[position.Height] = hash
+			} else {
+				hashes[position.Height] = m.Siblings[id]
+			}
+		}
+		proofs = append(proofs, NewProof(txo, hashes))
+	}
+	return proofs, nil
+}
+
+// Size returns encoded size of TXOs and sibling hashes in bytes.
+func (m Multiproof) Size() int {
+	return len(m.TXOs)*binary.Size(TXO{}) + len(m.Siblings)*32
+}
+
+// ProofBytes returns bytes of sibling hashes.
+func (m Multiproof) ProofBytes() int {
+	return len(m.Siblings) * 32
+}
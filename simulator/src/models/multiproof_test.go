@@ -0,0 +1,100 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"trail_simulator/simulator/src/types"
+)
+
+// spacedTXOs stores a block whose 10 TXOs are at every third leaf.
+// It returns reference tree of the block, its hash, TXOs and root.
+func spacedTXOs() (*ReferenceTree, [32]byte, []*TXO, [32]byte) {
+	blockHash := [32]byte{1}
+	Blocks[blockHash] = &Block{}
+	var txos []*TXO
+	for i := 0; i < 10; i++ {
+		txo := NewTXOWithoutIndex(NullHash[0], uint32(i), 1000)
+		txo.SetIndex(types.FromUint64(uint64(i * 3)))
+		txos = append(txos, txo)
+	}
+	reference := NewReferenceTree()
+	reference.AddBlock(blockHash, txos, nil)
+	return reference, blockHash, txos, reference.nodes(blockHash)[RootHeight][types.Uint256{}]
+}
+
+func TestMultiproof(t *testing.T) {
+	defer resetGlobals()()
+	reference, blockHash, txos, root := spacedTXOs()
+
+	tests := []struct {
+		name string
+		txos []*TXO
+	}{
+		{
+			name: "single TXO",
+			txos: txos[:1],
+		},
+		{
+			name: "adjacent TXOs",
+			txos: txos[:2],
+		},
+		{
+			name: "all TXOs",
+			txos: txos,
+		},
+		{
+			name: "apart TXOs",
+			txos: []*TXO{txos[0], txos[9]},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var proofs []*Proof
+			for _, txo := range tt.txos {
+				proofs = append(proofs, referenceProof(reference, blockHash, txo))
+			}
+			m, err := NewMultiproof(proofs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, err := m.Root(false); err != nil || got != root {
+				t.Errorf("Multiproof.Root() = %v, %v, want root of the block", got, err)
+			}
+			if got, err := m.Proofs(); err != nil || !reflect.DeepEqual(got, proofs) {
+				t.Errorf("Multiproof.Proofs() differ from the proofs multiproof was built from, %v", err)
+			}
+			if len(tt.txos) > 1 && m.ProofBytes() >= len(tt.txos)*255*32 {
+				t.Errorf("Multiproof.ProofBytes() = %d is not smaller than individual proofs", m.ProofBytes())
+			}
+		})
+	}
+
+	t.Run("inconsistent proofs", func(t *testing.T) {
+		tampered := referenceProof(reference, blockHash, txos[1])
+		tampered.Proofs[100] = [32]byte{1}
+		if _, err := NewMultiproof([]*Proof{referenceProof(reference, blockHash, txos[0]), tampered}); err == nil {
+			t.Error("NewMultiproof() succeeded with proofs differing at a branch")
+		}
+	})
+	t.Run("sibling on path", func(t *testing.T) {
+		m, _ := NewMultiproof(referenceProofs(reference, blockHash, txos[:2]))
+		// the parent of leaf 3 is the sibling of the parent of leaf 0, and is computed from TXO at leaf 3.
+		m.Siblings[BuildBranchID(1, types.Uint256{1})] = [32]byte{1}
+		if _, err := m.Root(false); err == nil {
+			t.Error("Multiproof.Root() succeeded with a hash of node on path of TXOs")
+		}
+		if _, err := m.Proofs(); err == nil {
+			t.Error("Multiproof.Proofs() succeeded with a hash of node on path of TXOs")
+		}
+		if NewBatchVerifier(root).VerifyMultiproof(m, false) {
+			t.Error("BatchVerifier.VerifyMultiproof() succeeded with a hash of node on path of TXOs")
+		}
+	})
+	t.Run("missing sibling", func(t *testing.T) {
+		m, _ := NewMultiproof([]*Proof{referenceProof(reference, blockHash, txos[0])})
+		delete(m.Siblings, BuildBranchID(0, types.Uint256{1}))
+		if _, err := m.Root(false); err == nil {
+			t.Error("Multiproof.Root() succeeded without a sibling")
+		}
+	})
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"crypto/sha256"
+	"encoding/binary"
 	"trail_simulator/simulator/src/setting"
 	"trail_simulator/simulator/src/types"
 )
@@ -20,13 +21,36 @@ func init() {
 
 // Node generate blocks.
 type Node struct {
-	ID     uint32
-	Client *Client
+	ID        uint32
+	Client    *Client
+	Mempool   *Mempool
+	Allocator IndexAllocator // decides leaf indexes of new TXOs.
+	Stats     BuildStats     // of the latest block node built.
+}
+
+// BuildStats is contents of a block and amount of work node did to build it.
+type BuildStats struct {
+	Transactions         int `json:"transactions"`
+	BlockSize            int `json:"block_size"` // bytes of header and transactions.
+	TotalFee             int `json:"total_fee"`
+	Inputs               int `json:"inputs"`
+	Outputs              int `json:"outputs"`
+	ProofBytes           int `json:"proof_bytes"`            // bytes of Merkle proofs of inputs.
+	IndividualProofBytes int `json:"individual_proof_bytes"` // bytes of Merkle proofs if each input had its own proof.
+	UpdatedTransactions  int `json:"updated_transactions"`   // included transactions whose proofs were against older block than parent block.
+	UpdatedProofs        int `json:"updated_proofs"`
+	UpdatedHashes        int `json:"updated_hashes"` // proof hashes replaced by newer branch hashes in included transactions.
+	LogLookups           int `json:"log_lookups"`    // lookups of branch logs to update proofs of included transactions.
+	ProofHashes          int `json:"proof_hashes"`   // hashes computed to verify proofs of inputs, including rejected ones.
 }
 
 // NewNode provide new node instance.
 func NewNode(id uint32, client *Client) *Node {
-	return &Node{ID: id, Client: client}
+	allocator, err := NewIndexAllocator(setting.IndexAllocation)
+	if err != nil {
+		panic(err)
+	}
+	return &Node{ID: id, Client: client, Mempool: NewMempool(), Allocator: allocator}
 }
 
 func (n *Node) validateTransactions(txs []*Transaction, parentHash [32]byte, parent Block) ([]*Proof, []*TXO, uint64) {
@@ -34,19 +58,57 @@ func (n *Node) validateTransactions(txs []*Transaction, parentHash [32]byte, par
 	var validOutputs []*TXO
 
 	totalFee := uint64(0)
+	spent := map[types.Uint256]bool{} // input TXOs of valid transactions.
+	verifier := NewBatchVerifier(parent.Root)
 	for _, tx := range txs {
-		if tx.BlockHash != parentHash {
+		if len(tx.Inputs) == 0 || len(tx.Outputs) == 0 || !tx.MultiproofMatchesInputs() {
+			continue
+		}
+		newerBlocks, recent := recentBlocks(tx.BlockHash, parentHash, setting.ProofValidityBlocks)
+		if !recent {
+			continue
+		}
+		inputs := tx.Inputs
+		multiproof := tx.Multiproof
+		var updates BuildStats // work of updating proofs, counted only if the transaction is included.
+		if multiproof != nil {
+			// proofs of inputs are taken from what transaction carries.
+			proofs, err := multiproof.Proofs()
+			if err != nil {
+				continue
+			}
+			inputs = proofs
+		}
+		if len(newerBlocks) > 0 {
+			inputs = n.updateProofs(inputs, newerBlocks, &updates)
+			updates.UpdatedTransactions = 1
+			if multiproof != nil {
+				updated, err := NewMultiproof(inputs)
+				if err != nil {
+					continue
+				}
+				multiproof = updated
+			}
+		}
+		if multiproof != nil && !verifier.VerifyMultiproof(multiproof, false) {
 			continue
 		}
 		totalInputBalance := uint64(0)
 
 		isInvalid := false
-		for _, proof := range tx.Inputs {
-			if proof.Root(false) != parent.Root {
+		txSpent := map[types.Uint256]bool{}
+		for _, proof := range inputs {
+			index := proof.TXO.Index
+			if spent[index] || txSpent[index] || (multiproof == nil && !verifier.Verify(proof, false)) {
 				isInvalid = true
 				break
 			}
+			txSpent[index] = true
 			totalInputBalance += proof.TXO.Balance
+			if totalInputBalance < proof.TXO.Balance {
+				isInvalid = true // overflow
+				break
+			}
 		}
 
 		if isInvalid {
@@ -56,20 +118,67 @@ func (n *Node) validateTransactions(txs []*Transaction, parentHash [32]byte, par
 		totalOutputBalance := uint64(0)
 		for _, txo := range tx.Outputs {
 			totalOutputBalance += txo.Balance
+			if txo.Balance == 0 || totalOutputBalance < txo.Balance {
+				isInvalid = true
+				break
+			}
 		}
 
-		if totalInputBalance < totalOutputBalance+uint64(len(tx.Inputs)*setting.FeePerTXO) {
+		// value conservation: inputs are outputs plus fee, and fee is at least required fee.
+		if isInvalid || totalInputBalance < totalOutputBalance || totalInputBalance-totalOutputBalance < tx.RequiredFee() {
 			continue
 		}
-		validProofs = append(validProofs, tx.Inputs...)
+		if n.Stats.BlockSize+tx.Size() > setting.MaxBlockSize {
+			continue
+		}
+		for index := range txSpent {
+			spent[index] = true
+		}
+		validProofs = append(validProofs, inputs...)
 		validOutputs = append(validOutputs, tx.Outputs...)
 		totalFee += totalInputBalance - totalOutputBalance
+		n.Stats.Transactions++
+		n.Stats.BlockSize += tx.Size()
+		n.Stats.Inputs += len(inputs)
+		n.Stats.Outputs += len(tx.Outputs)
+		n.Stats.ProofBytes += tx.ProofBytes()
+		n.Stats.IndividualProofBytes += len(inputs) * binary.Size([255][32]byte{})
+		n.Stats.UpdatedTransactions += updates.UpdatedTransactions
+		n.Stats.UpdatedProofs += updates.UpdatedProofs
+		n.Stats.UpdatedHashes += updates.UpdatedHashes
+		n.Stats.LogLookups += updates.LogLookups
+	}
+	n.Stats.ProofHashes = verifier.Hashes
+	return validProofs, validOutputs, totalFee
+}
 
-		if len(validProofs) >= setting.InputsPerBlock {
-			break
+// updateProofs returns copies of proofs updated to the latest branch hashes in newer blocks.
+// newerBlocks are hash values of blocks from parent block to the child of the block proofs are against.
+// The lookups and replaced hashes are counted in stats.
+func (n *Node) updateProofs(proofs []*Proof, newerBlocks [][32]byte, stats *BuildStats) []*Proof {
+	var updated []*Proof
+	for _, proof := range proofs {
+		hashes := proof.Proofs
+		for h, branchID := range getProofBranchIDs(proof.TXO.Index) {
+			branch, exists := Branches[branchID]
+			if !exists {
+				continue
+			}
+			for _, blockHash := range newerBlocks {
+				stats.LogLookups++
+				if hash, exists := branch.Log[blockHash]; exists {
+					if hash != hashes[h] {
+						hashes[h] = hash
+						stats.UpdatedHashes++
+					}
+					break
+				}
+			}
 		}
+		updated = append(updated, NewProof(proof.TXO, hashes))
+		stats.UpdatedProofs++
 	}
-	return validProofs, validOutputs, totalFee
+	return updated
 }
 
 func (n *Node) fillTreeWithProofs(
@@ -77,21 +186,13 @@ func (n *Node) fillTreeWithProofs(
 	indexes [255]map[types.Uint256]bool,
 	proofs []*Proof) (map[BranchID][32]byte, [255]map[types.Uint256]bool) {
 	for _, proof := range proofs {
-		index := proof.TXO.Index
-		for h := uint8(0); h < uint8(255); h++ {
-			if index[0]%2 == 0 {
-				index[0] += uint8(1)
-			} else {
-				index[0] -= uint8(1)
-			}
-			hash := proof.Proofs[h]
-			branchID := BuildBranchID(h, index)
-			branches[branchID] = hash
+		for position := LeafPosition(proof.TXO.Index); !position.IsRoot(); position = position.Parent() {
+			sibling := position.Sibling()
+			branches[sibling.BranchID()] = proof.Proofs[position.Height]
 
-			if index[0]%2 == 0 {
-				indexes[h][index] = true
+			if sibling.IsLeft() {
+				indexes[sibling.Height][sibling.Index] = true
 			}
-			index = index.Divide2()
 		}
 	}
 	return branches, indexes
@@ -101,25 +202,18 @@ func (n *Node) fillTreeWithParentBlock(
 	branches map[BranchID][32]byte,
 	indexes [255]map[types.Uint256]bool,
 	parent *Block) (map[BranchID][32]byte, [255]map[types.Uint256]bool) {
-	index := parent.RightmostIndex
-	branchID := BuildBranchID(0, index)
-	branches[branchID] = parent.RightmostHash
-	if index[0]%2 == 0 {
-		indexes[0][index] = true
+	rightmost := LeafPosition(parent.RightmostIndex)
+	branches[rightmost.BranchID()] = parent.RightmostHash
+	if rightmost.IsLeft() {
+		indexes[0][rightmost.Index] = true
 	}
 
-	for h := uint8(0); h < uint8(255); h++ {
-		if index[0]%2 == 0 {
-			index[0] += uint8(1)
-		} else {
-			index[0] -= uint8(1)
+	for position := rightmost; !position.IsRoot(); position = position.Parent() {
+		sibling := position.Sibling()
+		branches[sibling.BranchID()] = parent.RightmostProof[position.Height]
+		if sibling.IsLeft() {
+			indexes[sibling.Height][sibling.Index] = true
 		}
-		branchID = BuildBranchID(h, index)
-		branches[branchID] = parent.RightmostProof[h]
-		if index[0]%2 == 0 {
-			indexes[h][index] = true
-		}
-		index = index.Divide2()
 	}
 	return branches, indexes
 }
@@ -130,11 +224,10 @@ func (n *Node) fillTreeWithUsedTXOs(
 	proofs []*Proof) (map[BranchID][32]byte, [255]map[types.Uint256]bool, []*TXO) {
 	var usedTXOs []*TXO
 	for _, proof := range proofs {
-		index := proof.TXO.Index
-		branchID := BuildBranchID(0, index)
-		branches[branchID] = proof.TXO.Hash(true)
-		if index[0]%2 == 0 {
-			indexes[0][index] = true
+		leaf := LeafPosition(proof.TXO.Index)
+		branches[leaf.BranchID()] = proof.TXO.Hash(true)
+		if leaf.IsLeft() {
+			indexes[0][leaf.Index] = true
 		}
 		usedTXOs = append(usedTXOs, proof.TXO)
 	}
@@ -152,10 +245,10 @@ func (n *Node) fillTreeWithNewTXOs(
 		rightmostIndex = rightmostIndex.AddUint8(1)
 		txo.SetIndex(rightmostIndex)
 
-		branchID := BuildBranchID(0, rightmostIndex)
-		branches[branchID] = txo.Hash(false)
-		if rightmostIndex[0]%2 == 0 {
-			indexes[0][rightmostIndex] = true
+		leaf := LeafPosition(rightmostIndex)
+		branches[leaf.BranchID()] = txo.Hash(false)
+		if leaf.IsLeft() {
+			indexes[0][leaf.Index] = true
 		}
 		newTXOs = append(newTXOs, txo)
 	}
@@ -163,9 +256,9 @@ func (n *Node) fillTreeWithNewTXOs(
 }
 
 func (n *Node) getParentBranchHash(height uint8, leftIndex types.Uint256, branches map[BranchID][32]byte) ([32]byte, map[BranchID][32]byte) {
-	leftBranchID := BuildBranchID(height, leftIndex)
-	rightIndex := leftIndex.AddUint8(1)
-	rightBranchID := BuildBranchID(height, rightIndex)
+	left := TreePosition{height, leftIndex}
+	leftBranchID := left.BranchID()
+	rightBranchID := left.Sibling().BranchID()
 
 	leftHash := branches[leftBranchID]
 	rightHash, exists := branches[rightBranchID]
@@ -186,14 +279,13 @@ func (n *Node) calcTreeRoot(
 		for index := range heightindexes {
 			parentBranchHash, branches := n.getParentBranchHash(h, index, branches)
 
-			if h+1 == uint8(255) {
+			parent := TreePosition{h, index}.Parent()
+			if parent.IsRoot() {
 				treeRoot = parentBranchHash
 			} else {
-				parentIndex := index.Divide2()
-				parentBranchID := BuildBranchID(h+1, parentIndex)
-				branches[parentBranchID] = parentBranchHash
-				if parentIndex[0]%2 == 0 {
-					indexes[h+1][parentIndex] = true
+				branches[parent.BranchID()] = parentBranchHash
+				if parent.IsLeft() {
+					indexes[parent.Height][parent.Index] = true
 				}
 			}
 		}
@@ -235,18 +327,20 @@ func (n *Node) BuildGenesis(parentHash [32]byte, txos []*TXO) (map[BranchID][32]
 }
 
 // BuildBlock generate new block.
+// Transactions are included in the given order as long as they are valid and fit in the block.
 // BuildBlock returns branch hashes, newTXOs, usedTXOs, newBlock.
 func (n *Node) BuildBlock(txs []*Transaction) (map[BranchID][32]byte, []*TXO, []*TXO, *Block) {
-	if len(txs) == 0 {
-		panic("BuildBlock: cant build block without transactions")
-	}
-
+	n.Stats = BuildStats{BlockSize: binary.Size(Block{})}
 	parent := Blocks[n.Client.HeadBlock]
 	parentHash := n.Client.HeadBlock
+	if !OnCheckpointChain(parentHash) {
+		panic("BuildBlock: parent block is not on checkpoint chain")
+	}
 
 	validProofs, validOutputs, totalFee := n.validateTransactions(txs, parentHash, *parent)
+	n.Stats.TotalFee = int(totalFee)
 	rewardTXO := NewTXOWithoutIndex(parentHash, n.Client.Address, totalFee)
-	validOutputs = append(validOutputs, rewardTXO)
+	validOutputs = n.Allocator.Order(validOutputs, rewardTXO)
 
 	branches := map[BranchID][32]byte{}
 	var filledIndexes [255]map[types.Uint256]bool
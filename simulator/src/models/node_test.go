@@ -0,0 +1,347 @@
+package models
+
+import (
+	"encoding/binary"
+	"testing"
+	"trail_simulator/simulator/src/setting"
+)
+
+func TestNode_BuildBlock_Multiproof(t *testing.T) {
+	defer resetGlobals()()
+	clients := []*Client{NewClient(0), NewClient(1)}
+	node := NewNode(0, clients[0])
+	deliver := func(branches map[BranchID][32]byte, newTXOs []*TXO, usedTXOs []*TXO, block *Block) [32]byte {
+		blockHash := block.Hash()
+		branchIDs := StoreBlock(block, branches)
+		for _, client := range clients {
+			client.Update(branchIDs, newTXOs, usedTXOs, blockHash)
+		}
+		return blockHash
+	}
+	var genesisTXOs []*TXO
+	for i := 0; i < 4; i++ {
+		genesisTXOs = append(genesisTXOs, NewTXOWithoutIndex(NullHash[0], uint32(i%2), 1000000))
+	}
+	genesis := deliver(node.BuildGenesis(NullHash[0], genesisTXOs))
+	// multiproofTx returns transaction of client spending all its unused TXOs with multiproof.
+	multiproofTx := func(client *Client) *Transaction {
+		var inputs []*Proof
+		total := uint64(0)
+		for _, txo := range sortedTXOs(client.Unused[client.HeadBlock]) {
+			proof, err := client.BuildProof(txo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			inputs = append(inputs, proof)
+			total += txo.Balance
+		}
+		multiproof, err := NewMultiproof(inputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		output := NewTXOWithoutIndex(client.HeadBlock, client.Address, 0)
+		tx := &Transaction{BlockHash: client.HeadBlock, Inputs: inputs, Outputs: []*TXO{output}, Multiproof: multiproof}
+		output.Balance = total - tx.RequiredFee()
+		return tx
+	}
+	// build returns whether block built by node includes the transaction.
+	build := func(tx *Transaction) bool {
+		branches, newTXOs, usedTXOs, block := node.BuildBlock([]*Transaction{tx})
+		deliver(branches, newTXOs, usedTXOs, block)
+		return len(usedTXOs) > 0
+	}
+
+	older := multiproofTx(clients[1])
+	if !build(multiproofTx(clients[0])) {
+		t.Error("BuildBlock() didn't include transaction with valid multiproof")
+	}
+	if older.BlockHash != genesis || node.Client.HeadBlock == genesis {
+		t.Fatal("transaction isn't against an older block")
+	}
+	if !build(older) {
+		t.Error("BuildBlock() didn't include transaction with valid multiproof against an older recent block")
+	}
+	if node.Stats.UpdatedTransactions != 1 {
+		t.Errorf("BuildBlock() updated proofs of %d transactions, want 1", node.Stats.UpdatedTransactions)
+	}
+
+	tampered := multiproofTx(clients[0])
+	for id := range tampered.Multiproof.Siblings {
+		tampered.Multiproof.Siblings[id] = [32]byte{1}
+		break
+	}
+	if build(tampered) {
+		t.Error("BuildBlock() included transaction whose multiproof has tampered sibling")
+	}
+	mismatched := multiproofTx(clients[0])
+	mismatched.Inputs = mismatched.Inputs[:1]
+	if len(mismatched.Multiproof.TXOs) < 2 || build(mismatched) {
+		t.Error("BuildBlock() included transaction whose multiproof proves other TXOs than inputs")
+	}
+	if !build(multiproofTx(clients[0])) {
+		t.Error("BuildBlock() didn't include transaction with valid multiproof after rejecting invalid ones")
+	}
+}
+
+func TestNode_BuildBlock_RecentProofs(t *testing.T) {
+	defer resetGlobals()()
+	clients := []*Client{NewClient(0), NewClient(1), NewClient(2), NewClient(3)}
+	node := NewNode(3, clients[3])
+	deliver := func(branches map[BranchID][32]byte, newTXOs []*TXO, usedTXOs []*TXO, block *Block) [32]byte {
+		blockHash := block.Hash()
+		branchIDs := StoreBlock(block, branches)
+		for _, client := range clients {
+			client.Update(branchIDs, newTXOs, usedTXOs, blockHash)
+		}
+		return blockHash
+	}
+	// build returns whether block built by node includes each of the transactions.
+	build := func(txs ...*Transaction) []bool {
+		branches, newTXOs, usedTXOs, block := node.BuildBlock(txs)
+		deliver(branches, newTXOs, usedTXOs, block)
+		spent := map[*TXO]bool{}
+		for _, txo := range usedTXOs {
+			spent[txo] = true
+		}
+		included := make([]bool, len(txs))
+		for i, tx := range txs {
+			included[i] = len(tx.Inputs) > 0 && spent[tx.Inputs[0].TXO]
+		}
+		return included
+	}
+	var genesisTXOs []*TXO
+	for id := 0; id < 3; id++ {
+		genesisTXOs = append(genesisTXOs, NewTXOWithoutIndex(NullHash[0], uint32(id), 1000000))
+	}
+	genesis := deliver(node.BuildGenesis(NullHash[0], genesisTXOs))
+	selector, _ := NewCoinSelector("largest-first")
+	pay := func(sender *Client, receiver *Client) *Transaction {
+		tx, err := BuildPayment(sender, receiver, 1000, selector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+	// every transaction is against genesis.
+	stale := pay(clients[0], clients[3])
+	first := pay(clients[1], clients[3])
+	double := pay(clients[1], clients[2]) // spends the same TXO as first.
+	tooOld := pay(clients[2], clients[3])
+
+	if !build(first)[0] {
+		t.Fatal("BuildBlock() didn't include transaction against parent block")
+	}
+	for len(Blocks) < int(setting.ProofValidityBlocks) {
+		build()
+	}
+
+	// proofs against the oldest recent block are updated to the root of parent block.
+	head := node.Client.HeadBlock
+	newerBlocks, recent := recentBlocks(genesis, head, setting.ProofValidityBlocks)
+	if !recent || len(newerBlocks) != int(setting.ProofValidityBlocks)-1 {
+		t.Fatalf("recentBlocks() = %d blocks, %v, want %d blocks, true", len(newerBlocks), recent, setting.ProofValidityBlocks-1)
+	}
+	var stats BuildStats
+	updated := node.updateProofs(stale.Inputs, newerBlocks, &stats)
+	if stale.Inputs[0].Root(false) == Blocks[head].Root || updated[0].Root(false) != Blocks[head].Root {
+		t.Error("updateProofs() didn't update stale proof to the root of parent block")
+	}
+	if stats.UpdatedProofs != 1 || stats.UpdatedHashes == 0 || stats.LogLookups == 0 {
+		t.Errorf("updateProofs() counted %+v", stats)
+	}
+
+	if included := build(stale, double); !included[0] || included[1] {
+		t.Errorf("BuildBlock() included updated transaction %v and transaction spending spent input %v, want true and false",
+			included[0], included[1])
+	}
+	if node.Stats.UpdatedTransactions != 1 || node.Stats.UpdatedProofs != 1 {
+		t.Errorf("BuildBlock() counted %d updated transactions and %d proofs, want 1 and 1 of the included transaction",
+			node.Stats.UpdatedTransactions, node.Stats.UpdatedProofs)
+	}
+	if _, recent := recentBlocks(genesis, node.Client.HeadBlock, setting.ProofValidityBlocks); recent || build(tooOld)[0] {
+		t.Error("BuildBlock() included transaction against block older than recent blocks")
+	}
+}
+
+func TestNode_BuildBlock_Packing(t *testing.T) {
+	defer resetGlobals()()
+	node := NewNode(0, NewClient(0))
+	// numbers of inputs of transactions. The first fills half of the block,
+	// the second doesn't fit with the first, and smaller ones after it fit.
+	capacity := (setting.MaxBlockSize - binary.Size(Block{})) / InputSize
+	sizes := []int{capacity / 2, capacity - capacity/2 + 1, 1, 2}
+	var genesisTXOs []*TXO
+	for _, inputs := range sizes {
+		for i := 0; i < inputs; i++ {
+			genesisTXOs = append(genesisTXOs, NewTXOWithoutIndex(NullHash[0], 0, 1000000))
+		}
+	}
+	branches, newTXOs, usedTXOs, block := node.BuildGenesis(NullHash[0], genesisTXOs)
+	genesis := block.Hash()
+	node.Client.Update(StoreBlock(block, branches), newTXOs, usedTXOs, genesis)
+
+	unused := sortedTXOs(node.Client.Unused[genesis])
+	mempool := NewMempool()
+	var txs []*Transaction
+	for i, inputs := range sizes {
+		tx := &Transaction{BlockHash: genesis}
+		for _, txo := range unused[:inputs] {
+			proof, err := node.Client.BuildProof(txo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tx.Inputs = append(tx.Inputs, proof)
+		}
+		unused = unused[inputs:]
+		// fee rates descend in order of sizes.
+		tx.Outputs = []*TXO{NewTXOWithoutIndex(genesis, 0, 0)}
+		tx.Outputs[0].Balance = uint64(inputs)*1000000 - tx.RequiredFee()*uint64(len(sizes)-i)
+		txs = append(txs, tx)
+		mempool.Add(tx, genesis)
+	}
+
+	sorted := mempool.Sorted()
+	for i, tx := range txs {
+		if sorted[i] != tx {
+			t.Fatalf("Mempool.Sorted()[%d] isn't transaction of %d inputs", i, sizes[i])
+		}
+	}
+	_, _, usedTXOs, _ = node.BuildBlock(sorted)
+	spent := map[*TXO]bool{}
+	for _, txo := range usedTXOs {
+		spent[txo] = true
+	}
+	for i, want := range []bool{true, false, true, true} {
+		if got := spent[txs[i].Inputs[0].TXO]; got != want {
+			t.Errorf("BuildBlock() included transaction of %d inputs = %v, want %v", sizes[i], got, want)
+		}
+	}
+	wantSize := binary.Size(Block{}) + txs[0].Size() + txs[2].Size() + txs[3].Size()
+	if node.Stats.Transactions != 3 || node.Stats.BlockSize != wantSize || wantSize > setting.MaxBlockSize {
+		t.Errorf("BuildBlock() packed %d transactions in %d bytes, want 3 in %d bytes", node.Stats.Transactions, node.Stats.BlockSize, wantSize)
+	}
+}
+
+func TestNode_validateTransactions(t *testing.T) {
+	defer resetGlobals()()
+	clients := []*Client{NewClient(0), NewClient(1), NewClient(2), NewClient(3)}
+	node := NewNode(0, clients[0])
+	balances := map[uint32][]uint64{
+		0: {1000000, 1000000, 1000000, 1000000, 1000000, 1000000, 1 << 63, 1<<63 + 1000000},
+		1: {1000000, 1000000},
+		2: {1000000, 1000000},
+	}
+	var genesisTXOs []*TXO
+	for address := uint32(0); address < 3; address++ {
+		for _, balance := range balances[address] {
+			genesisTXOs = append(genesisTXOs, NewTXOWithoutIndex(NullHash[0], address, balance))
+		}
+	}
+	branches, newTXOs, usedTXOs, block := node.BuildGenesis(NullHash[0], genesisTXOs)
+	genesis := block.Hash()
+	branchIDs := StoreBlock(block, branches)
+	for _, client := range clients {
+		client.Update(branchIDs, newTXOs, usedTXOs, genesis)
+	}
+
+	// TXOs of client 0 in order of balances above.
+	unused := sortedTXOs(clients[0].Unused[genesis])
+	// tx returns transaction spending TXOs of client 0 to outputs of the balances.
+	// Outputs of -1 balance pay the rest after the required fee.
+	tx := func(inputs []*TXO, outputs ...int64) *Transaction {
+		tx := &Transaction{BlockHash: genesis}
+		total := uint64(0)
+		for _, txo := range inputs {
+			proof, err := clients[0].BuildProof(txo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tx.Inputs = append(tx.Inputs, proof)
+			total += txo.Balance
+		}
+		rest := -1
+		for i, balance := range outputs {
+			if balance < 0 {
+				rest = i
+			}
+			tx.Outputs = append(tx.Outputs, NewTXOWithoutIndex(genesis, 1, uint64(balance)))
+		}
+		if rest >= 0 {
+			tx.Outputs[rest].Balance = total - tx.RequiredFee()
+			for i, txo := range tx.Outputs {
+				if i != rest {
+					tx.Outputs[rest].Balance -= txo.Balance
+				}
+			}
+		}
+		return tx
+	}
+	requiredFee := int64(RequiredFee(1, 1))
+
+	selector, _ := NewCoinSelector("largest-first")
+	multiParty, err := BuildMultiPartyTransaction(
+		[]Payment{{clients[1], 1500000}, {clients[2], 500000}},
+		[]Payment{{clients[3], 1200000}, {clients[0], 800000}},
+		selector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(multiParty.Inputs) != 3 || len(multiParty.Outputs) != 4 {
+		t.Fatalf("BuildMultiPartyTransaction() = %d inputs and %d outputs, want 3 and 4", len(multiParty.Inputs), len(multiParty.Outputs))
+	}
+	// the first sender pays the whole fee, and the other sender gets the exact change.
+	if multiParty.Fee() != multiParty.RequiredFee() ||
+		multiParty.Outputs[2].Balance != 2000000-1500000-multiParty.RequiredFee() || multiParty.Outputs[3].Balance != 500000 {
+		t.Errorf("BuildMultiPartyTransaction() has fee %d and changes %d and %d, want %d, %d and 500000",
+			multiParty.Fee(), multiParty.Outputs[2].Balance, multiParty.Outputs[3].Balance,
+			multiParty.RequiredFee(), 2000000-1500000-multiParty.RequiredFee())
+	}
+	overpaid := *multiParty
+	overpaid.Outputs = append([]*TXO{}, multiParty.Outputs...)
+	overpaid.Outputs[0] = NewTXOWithoutIndex(genesis, 3, multiParty.Outputs[0].Balance+multiParty.Fee()+1)
+	duplicated := *multiParty
+	duplicated.Inputs = append([]*Proof{multiParty.Inputs[0]}, multiParty.Inputs...)
+
+	tests := []struct {
+		name string
+		tx   *Transaction
+		want bool
+	}{
+		{"valid", tx(unused[:1], -1), true},
+		{"fee above required", tx(unused[:1], 1000000-2*requiredFee), true},
+		{"outputs exceed inputs", tx(unused[:1], 1000001), false},
+		{"fee below required", tx(unused[:1], 1000000-requiredFee+1), false},
+		{"no outputs", tx(unused[:1]), false},
+		{"no inputs", tx(nil, 1), false},
+		{"zero output", tx(unused[:1], 0, -1), false},
+		// outputs of 4 * 2^62 + 1 wrap around to 1 without overflow check.
+		{"outputs overflow", tx(unused[:1], 1<<62, 1<<62, 1<<62, 1<<62, 1), false},
+		// inputs of 2^63 and 2^63+1000000 wrap around to 1000000 without overflow check.
+		{"inputs overflow", tx(unused[6:8], 1000000-2*requiredFee), false},
+		{"input spent twice", tx([]*TXO{unused[0], unused[0]}, 1000000), false},
+		{"multi-party", multiParty, true},
+		{"multi-party paying more than inputs", &overpaid, false},
+		{"multi-party spending input twice", &duplicated, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proofs, outputs, fee := node.validateTransactions([]*Transaction{tt.tx}, genesis, *Blocks[genesis])
+			if got := len(proofs) > 0; got != tt.want {
+				t.Fatalf("validateTransactions() included transaction = %v, want %v", got, tt.want)
+			}
+			if tt.want && (len(outputs) != len(tt.tx.Outputs) || fee != tt.tx.Fee()) {
+				t.Errorf("validateTransactions() = %d outputs and fee %d, want %d and %d", len(outputs), fee, len(tt.tx.Outputs), tt.tx.Fee())
+			}
+		})
+	}
+
+	t.Run("co-sender short of its amount", func(t *testing.T) {
+		_, err := BuildMultiPartyTransaction(
+			[]Payment{{clients[1], 500000}, {clients[2], 2000001}},
+			[]Payment{{clients[3], 2500001}},
+			selector)
+		if err == nil {
+			t.Error("BuildMultiPartyTransaction() succeeded with co-sender paying more than its balance")
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package models
+
+import "trail_simulator/simulator/src/types"
+
+// RootHeight is the height of the root of the TXO tree. Leaves are at height 0.
+const RootHeight = 255
+
+// TreePosition identifies a node in the TXO tree by its height and index in that height.
+// Leaf indexes are below 2^255, so the root is at index 0.
+type TreePosition struct {
+	Height uint8
+	Index  types.Uint256
+}
+
+// LeafPosition returns position of the leaf assigned the index.
+func LeafPosition(index types.Uint256) TreePosition {
+	return TreePosition{0, index}
+}
+
+// IsRoot reports whether p is the root.
+func (p TreePosition) IsRoot() bool {
+	return p.Height == RootHeight
+}
+
+// IsLeft reports whether p is the left child of its parent.
+func (p TreePosition) IsLeft() bool {
+	return p.Index.Bit(0) == 0
+}
+
+// Sibling returns the other child of p's parent.
+func (p TreePosition) Sibling() TreePosition {
+	return TreePosition{p.Height, p.Index.SetBit(0, p.Index.Bit(0)^1)}
+}
+
+// Parent returns the parent of p. p must not be the root.
+func (p TreePosition) Parent() TreePosition {
+	if p.IsRoot() {
+		panic("TreePosition.Parent: root has no parent")
+	}
+	return TreePosition{p.Height + 1, p.Index.Rsh(1)}
+}
+
+// PathToRoot returns p and its ancestors in ascending order of height, ending with the root.
+// Siblings of the positions except the root are the Merkle proof of p.
+func (p TreePosition) PathToRoot() []TreePosition {
+	path := make([]TreePosition, 0, RootHeight+1-int(p.Height))
+	for ; !p.IsRoot(); p = p.Parent() {
+		path = append(path, p)
+	}
+	return append(path, p)
+}
+
+// CommonAncestor returns the lowest position whose subtree contains both p and q.
+// Proofs of p and q share the siblings of the common ancestor and its ancestors.
+func (p TreePosition) CommonAncestor(q TreePosition) TreePosition {
+	for p.Height < q.Height {
+		p = p.Parent()
+	}
+	for q.Height < p.Height {
+		q = q.Parent()
+	}
+	for p != q && !p.IsRoot() {
+		p, q = p.Parent(), q.Parent()
+	}
+	return p
+}
+
+// BranchID returns id of the branch stored at p.
+func (p TreePosition) BranchID() BranchID {
+	return BuildBranchID(p.Height, p.Index)
+}
+
+// Position returns the position of the node id identifies.
+func (id BranchID) Position() TreePosition {
+	return TreePosition{id.Height(), id.Index()}
+}
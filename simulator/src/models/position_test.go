@@ -0,0 +1,73 @@
+package models
+
+import (
+	"testing"
+	"trail_simulator/simulator/src/types"
+)
+
+func TestTreePosition_CommonAncestor(t *testing.T) {
+	tests := []struct {
+		name string
+		p    TreePosition
+		q    TreePosition
+		want TreePosition
+	}{
+		{
+			name: "same leaf",
+			p:    LeafPosition(types.Uint256{5}),
+			q:    LeafPosition(types.Uint256{5}),
+			want: LeafPosition(types.Uint256{5}),
+		},
+		{
+			name: "siblings",
+			p:    LeafPosition(types.Uint256{4}),
+			q:    LeafPosition(types.Uint256{5}),
+			want: TreePosition{1, types.Uint256{2}},
+		},
+		{
+			name: "leaves in different halves of subtree of 8 leaves",
+			p:    LeafPosition(types.Uint256{3}),
+			q:    LeafPosition(types.Uint256{4}),
+			want: TreePosition{3, types.Uint256{0}},
+		},
+		{
+			name: "leaf and its ancestor",
+			p:    LeafPosition(types.Uint256{0, 1}),
+			q:    TreePosition{4, types.Uint256{16}},
+			want: TreePosition{4, types.Uint256{16}},
+		},
+		{
+			name: "leaves in different halves of tree",
+			p:    LeafPosition(types.Uint256{}),
+			q:    LeafPosition(types.Uint256{}.SetBit(254, 1)),
+			want: TreePosition{RootHeight, types.Uint256{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.CommonAncestor(tt.q); got != tt.want {
+				t.Errorf("TreePosition.CommonAncestor() = %v, want %v", got, tt.want)
+			}
+			if got := tt.q.CommonAncestor(tt.p); got != tt.want {
+				t.Errorf("TreePosition.CommonAncestor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTreePosition_PathToRoot(t *testing.T) {
+	leaf := LeafPosition(types.Uint256{6})
+	path := leaf.PathToRoot()
+	if len(path) != RootHeight+1 || path[0] != leaf || !path[RootHeight].IsRoot() {
+		t.Fatalf("TreePosition.PathToRoot() has %d positions from %v to %v", len(path), path[0], path[len(path)-1])
+	}
+	branchIDs := getProofBranchIDs(leaf.Index)
+	for h, position := range path[:RootHeight] {
+		if position.Sibling().BranchID() != branchIDs[h] {
+			t.Errorf("sibling of %v is not proof branch at height %d", position, h)
+		}
+	}
+	if !path[0].IsLeft() || path[1].IsLeft() || path[2].IsLeft() || !path[3].IsLeft() {
+		t.Errorf("ancestors of leaf 6 are left, right, right, left child")
+	}
+}
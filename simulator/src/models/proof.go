@@ -2,6 +2,7 @@ package models
 
 import (
 	"crypto/sha256"
+	"encoding/json"
 	"trail_simulator/simulator/src/types"
 )
 
@@ -16,35 +17,38 @@ func NewProof(txo *TXO, proofs [255][32]byte) *Proof {
 	return &Proof{txo, proofs}
 }
 
+// MarshalJSON encodes proof with the index of TXO and the sibling hash at each height in hex.
+func (p Proof) MarshalJSON() ([]byte, error) {
+	proofs := make([]string, len(p.Proofs))
+	for h, hash := range p.Proofs {
+		proofs[h] = HashString(hash)
+	}
+	return json.Marshal(struct {
+		Index  types.Uint256 `json:"index"`
+		Owner  uint32        `json:"owner"`
+		Proofs []string      `json:"proofs"`
+	}{p.TXO.Index, p.TXO.OwnerAddress, proofs})
+}
+
 // Root returns the root calucurated from a leaf and its merkle proof.
 func (p Proof) Root(isUsed bool) [32]byte {
 	hash := p.TXO.Hash(isUsed)
-	index := p.TXO.Index
-	for h := uint8(0); h < uint8(255); h++ {
-		if index[0]%2 == 0 {
-			s := append(hash[:], p.Proofs[h][:]...)
+	for position := LeafPosition(p.TXO.Index); !position.IsRoot(); position = position.Parent() {
+		if position.IsLeft() {
+			s := append(hash[:], p.Proofs[position.Height][:]...)
 			hash = sha256.Sum256(s)
 		} else {
-			s := append(p.Proofs[h][:], hash[:]...)
+			s := append(p.Proofs[position.Height][:], hash[:]...)
 			hash = sha256.Sum256(s)
 		}
-		index = index.Divide2()
 	}
 	return hash
 }
 
 func getProofBranchIDs(leafIndex types.Uint256) [255]BranchID {
 	var branchIDs [255]BranchID
-	index := leafIndex
-	for h := uint8(0); h < uint8(255); h++ {
-		if index[0]%2 == 0 {
-			index[0] += uint8(1)
-		} else {
-			index[0] -= uint8(1)
-		}
-		branchID := BuildBranchID(h, index)
-		branchIDs[h] = branchID
-		index = index.Divide2()
+	for position := LeafPosition(leafIndex); !position.IsRoot(); position = position.Parent() {
+		branchIDs[position.Height] = position.Sibling().BranchID()
 	}
 	return branchIDs
 }
@@ -0,0 +1,144 @@
+//go:build go1.18
+// +build go1.18
+
+package models
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+	"testing"
+	"trail_simulator/simulator/src/types"
+)
+
+// bigIndex converts little endian index to big.Int.
+func bigIndex(index types.Uint256) *big.Int {
+	var be [32]byte
+	for i := 0; i < 32; i++ {
+		be[31-i] = index[i]
+	}
+	return new(big.Int).SetBytes(be[:])
+}
+
+func FuzzGetProofBranchIDs(f *testing.F) {
+	f.Add([]byte{0})
+	f.Add([]byte{1})
+	f.Add([]byte{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255,
+		255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 127})
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var leaf types.Uint256
+		copy(leaf[:], b)
+		for h, branchID := range getProofBranchIDs(leaf) {
+			// sibling at height h is the node of index (leaf >> h) ^ 1.
+			want := new(big.Int).Rsh(bigIndex(leaf), uint(h))
+			want.SetBit(want, 0, want.Bit(0)^1)
+			if int(branchID.Height()) != h || bigIndex(branchID.Index()).Cmp(want) != 0 {
+				t.Fatalf("getProofBranchIDs(%v)[%d] = height %d index %x, want height %d index %x",
+					leaf, h, branchID.Height(), bigIndex(branchID.Index()), h, want)
+			}
+		}
+	})
+}
+
+func FuzzProof_Root(f *testing.F) {
+	f.Add([]byte{0}, uint32(0), uint64(1), false)
+	f.Add([]byte{1, 2, 3}, uint32(7), uint64(100), true)
+	f.Fuzz(func(t *testing.T, b []byte, address uint32, balance uint64, isUsed bool) {
+		txo := &TXO{OwnerAddress: address, Balance: balance}
+		copy(txo.Index[:], b)
+		branchIDs := getProofBranchIDs(txo.Index)
+		proofs := [255][32]byte{}
+		for h, branchID := range branchIDs {
+			proofs[h] = sha256.Sum256(branchID[:])
+		}
+
+		// the sibling is left of the path to the root if its index is less.
+		hash := txo.Hash(isUsed)
+		index := txo.Index
+		for h, branchID := range branchIDs {
+			if branchID.Index().Larger(index) {
+				hash = sha256.Sum256(append(hash[:], proofs[h][:]...))
+			} else {
+				hash = sha256.Sum256(append(proofs[h][:], hash[:]...))
+			}
+			index = index.Divide2()
+		}
+		if got := NewProof(txo, proofs).Root(isUsed); got != hash {
+			t.Errorf("Proof.Root() = %x, want %x", got, hash)
+		}
+	})
+}
+
+func FuzzBranchID(f *testing.F) {
+	f.Add(uint8(0), []byte{0})
+	f.Add(uint8(254), []byte{255, 1})
+	f.Fuzz(func(t *testing.T, height uint8, b []byte) {
+		var index types.Uint256
+		copy(index[:], b)
+		branchID := BuildBranchID(height, index)
+		if branchID.Height() != height || branchID.Index() != index {
+			t.Errorf("BuildBranchID(%d, %v) decodes to height %d index %v", height, index, branchID.Height(), branchID.Index())
+		}
+		parsed, err := ParseBranchID(branchID.String())
+		if height >= RootHeight {
+			if err == nil {
+				t.Errorf("ParseBranchID(%q) succeeded above the tree", branchID.String())
+			}
+		} else if err != nil || parsed != branchID {
+			t.Errorf("ParseBranchID(%q) = %v, %v", branchID.String(), parsed, err)
+		}
+	})
+}
+
+func FuzzTXO_Encoding(f *testing.F) {
+	f.Add([]byte{0}, []byte{0}, uint32(0), uint64(0))
+	f.Add([]byte{1, 2}, []byte{3, 4}, uint32(5), uint64(6))
+	f.Fuzz(func(t *testing.T, index []byte, parent []byte, address uint32, balance uint64) {
+		txo := TXO{OwnerAddress: address, Balance: balance}
+		copy(txo.Index[:], index)
+		copy(txo.ParentBlockHash[:], parent)
+		var buf bytes.Buffer
+		if err := binary.Write(&buf, binary.BigEndian, txo); err != nil {
+			t.Fatal(err)
+		}
+		if buf.Len() != SerializedSize.TXO {
+			t.Errorf("encoded TXO is %d bytes, want %d", buf.Len(), SerializedSize.TXO)
+		}
+		var decoded TXO
+		if err := binary.Read(&buf, binary.BigEndian, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if decoded != txo || decoded.Hash(false) != txo.Hash(false) || decoded.Hash(true) != txo.Hash(true) {
+			t.Errorf("TXO %+v decodes to %+v", txo, decoded)
+		}
+	})
+}
+
+func FuzzBlock_Encoding(f *testing.F) {
+	f.Add([]byte{0}, uint64(0), []byte{0}, []byte{0})
+	f.Add([]byte{1}, uint64(2), []byte{3}, []byte{4, 5})
+	f.Fuzz(func(t *testing.T, parent []byte, height uint64, root []byte, rightmost []byte) {
+		block := Block{Height: height}
+		copy(block.Parent[:], parent)
+		copy(block.Root[:], root)
+		copy(block.RightmostIndex[:], rightmost)
+		for h := range block.RightmostProof {
+			block.RightmostProof[h] = sha256.Sum256(append(block.Root[:], byte(h)))
+		}
+		var buf bytes.Buffer
+		if err := binary.Write(&buf, binary.BigEndian, block); err != nil {
+			t.Fatal(err)
+		}
+		if buf.Len() != SerializedSize.Header {
+			t.Errorf("encoded block is %d bytes, want %d", buf.Len(), SerializedSize.Header)
+		}
+		var decoded Block
+		if err := binary.Read(&buf, binary.BigEndian, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if decoded != block || decoded.Hash() != block.Hash() {
+			t.Errorf("block at height %d doesn't round-trip", height)
+		}
+	})
+}
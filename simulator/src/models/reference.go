@@ -0,0 +1,111 @@
+package models
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"trail_simulator/simulator/src/types"
+)
+
+// Mismatches between node and reference tree.
+const (
+	ReferenceRootInvariant      = "reference_root"
+	ReferenceRightmostInvariant = "reference_rightmost" // rightmost hash or proof in block header.
+	ReferenceBranchInvariant    = "reference_branch"    // branch hash node produced for the block.
+)
+
+// ReferenceTree is a simple Merkle tree which keeps every leaf of each block and
+// recomputes the whole sparse tree, used to check incremental construction of nodes.
+type ReferenceTree struct {
+	leaves map[[32]byte]map[types.Uint256][32]byte // leaf hashes at each block.
+}
+
+// NewReferenceTree provides reference tree which knows no blocks.
+func NewReferenceTree() *ReferenceTree {
+	return &ReferenceTree{leaves: map[[32]byte]map[types.Uint256][32]byte{}}
+}
+
+// AddBlock records leaves of the block, which are leaves of the parent block
+// with used TXOs marked as used and new TXOs appended.
+func (r *ReferenceTree) AddBlock(blockHash [32]byte, newTXOs []*TXO, usedTXOs []*TXO) {
+	leaves := map[types.Uint256][32]byte{}
+	if block := Blocks[blockHash]; block.Height > 0 {
+		for index, hash := range r.leaves[block.Parent] {
+			leaves[index] = hash
+		}
+	}
+	for _, txo := range usedTXOs {
+		leaves[txo.Index] = txo.Hash(true)
+	}
+	for _, txo := range newTXOs {
+		leaves[txo.Index] = txo.Hash(false)
+	}
+	r.leaves[blockHash] = leaves
+}
+
+// nodes returns hashes of all non-empty nodes of the tree at the block for each height.
+// The last element is the root.
+func (r *ReferenceTree) nodes(blockHash [32]byte) [256]map[types.Uint256][32]byte {
+	var nodes [256]map[types.Uint256][32]byte
+	nodes[0] = r.leaves[blockHash]
+	for h := 0; h < 255; h++ {
+		nodes[h+1] = map[types.Uint256][32]byte{}
+		for index := range nodes[h] {
+			left, right := TreePosition{uint8(h), index}, TreePosition{uint8(h), index}.Sibling()
+			if !left.IsLeft() {
+				left, right = right, left
+			}
+			leftHash := nodeHash(nodes, h, left.Index)
+			rightHash := nodeHash(nodes, h, right.Index)
+			nodes[h+1][left.Parent().Index] = sha256.Sum256(append(leftHash[:], rightHash[:]...))
+		}
+	}
+	return nodes
+}
+
+// nodeHash returns hash of the node, which is null hash of the height if the subtree is empty.
+func nodeHash(nodes [256]map[types.Uint256][32]byte, height int, index types.Uint256) [32]byte {
+	if hash, exists := nodes[height][index]; exists {
+		return hash
+	}
+	return NullHash[height]
+}
+
+// Compare checks root and rightmost leaf of the block and branch hashes node produced for it
+// against the reference tree. It returns mismatches found.
+func (r *ReferenceTree) Compare(blockHash [32]byte, branches map[BranchID][32]byte) []Violation {
+	var mismatches []Violation
+	block := Blocks[blockHash]
+	nodes := r.nodes(blockHash)
+	var root [32]byte
+	for _, hash := range nodes[255] {
+		root = hash
+	}
+	if root != block.Root {
+		mismatches = append(mismatches, Violation{Invariant: ReferenceRootInvariant, Detail: "root differs"})
+	}
+
+	if nodeHash(nodes, 0, block.RightmostIndex) != block.RightmostHash {
+		mismatches = append(mismatches, Violation{
+			Invariant: ReferenceRightmostInvariant,
+			Index:     block.RightmostIndex.String(),
+			Detail:    "rightmost hash differs"})
+	}
+	for h, siblingID := range getProofBranchIDs(block.RightmostIndex) {
+		if nodeHash(nodes, h, siblingID.Index()) != block.RightmostProof[h] {
+			mismatches = append(mismatches, Violation{
+				Invariant: ReferenceRightmostInvariant,
+				Index:     block.RightmostIndex.String(),
+				Detail:    fmt.Sprintf("rightmost proof differs at height %d", h)})
+		}
+	}
+
+	for branchID, hash := range branches {
+		if nodeHash(nodes, int(branchID.Height()), branchID.Index()) != hash {
+			mismatches = append(mismatches, Violation{
+				Invariant: ReferenceBranchInvariant,
+				Index:     branchID.Index().String(),
+				Detail:    fmt.Sprintf("hash of branch %s differs", branchID)})
+		}
+	}
+	return mismatches
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// buildChain builds blocks in which random pairs of clients merge their TXOs, and checks each block
+// against reference tree. If forks is true, a sibling block of each block is also built and checked.
+func buildChain(t *testing.T, numberOfClient int, numberOfBlock int, forks bool, tamper bool) int {
+	Blocks = map[[32]byte]*Block{}
+	Branches = map[BranchID]*Branch{}
+	Checkpoints = nil
+	rand.Seed(1)
+
+	var clients []*Client
+	var genesisTXOs []*TXO
+	for id := 0; id < numberOfClient; id++ {
+		clients = append(clients, NewClient(uint32(id)))
+		genesisTXOs = append(genesisTXOs, NewTXOWithoutIndex(NullHash[0], uint32(id), 1000000))
+	}
+	node := NewNode(0, clients[0])
+	reference := NewReferenceTree()
+
+	mismatches := 0
+	store := func(branches map[BranchID][32]byte, newTXOs []*TXO, usedTXOs []*TXO, block *Block) [32]byte {
+		blockHash := block.Hash()
+		branchIDs := StoreBlock(block, branches)
+		if tamper {
+			for branchID := range branches {
+				branches[branchID] = [32]byte{1}
+				break
+			}
+		}
+		reference.AddBlock(blockHash, newTXOs, usedTXOs)
+		for _, mismatch := range reference.Compare(blockHash, branches) {
+			t.Logf("height %d: %s %s %s", block.Height, mismatch.Invariant, mismatch.Index, mismatch.Detail)
+			mismatches++
+		}
+		for _, client := range clients {
+			client.Update(branchIDs, newTXOs, usedTXOs, blockHash)
+		}
+		return blockHash
+	}
+	store(node.BuildGenesis(NullHash[0], genesisTXOs))
+
+	for i := 0; i < numberOfBlock; i++ {
+		var txs []*Transaction
+		perm := rand.Perm(numberOfClient)
+		for j := 0; j+1 < len(perm); j += 2 {
+			tx, err := BuildTransaction(clients[perm[j]], clients[perm[j+1]])
+			if err == nil {
+				txs = append(txs, tx)
+			}
+		}
+		if forks {
+			branches, newTXOs, usedTXOs, block := node.BuildBlock(txs[:len(txs)/2])
+			blockHash := block.Hash()
+			StoreBlock(block, branches)
+			reference.AddBlock(blockHash, newTXOs, usedTXOs)
+			mismatches += len(reference.Compare(blockHash, branches))
+		}
+		store(node.BuildBlock(txs))
+	}
+	return mismatches
+}
+
+func TestReferenceTree_Compare(t *testing.T) {
+	tests := []struct {
+		name           string
+		numberOfClient int
+		numberOfBlock  int
+		forks          bool
+		tamper         bool
+		wantMismatch   bool
+	}{
+		{
+			name:           "single client",
+			numberOfClient: 1,
+			numberOfBlock:  3,
+		},
+		{
+			name:           "odd number of leaves",
+			numberOfClient: 7,
+			numberOfBlock:  10,
+		},
+		{
+			name:           "many leaves",
+			numberOfClient: 64,
+			numberOfBlock:  10,
+		},
+		{
+			name:           "forks",
+			numberOfClient: 16,
+			numberOfBlock:  10,
+			forks:          true,
+		},
+		{
+			name:           "tampered branch",
+			numberOfClient: 16,
+			numberOfBlock:  2,
+			tamper:         true,
+			wantMismatch:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildChain(t, tt.numberOfClient, tt.numberOfBlock, tt.forks, tt.tamper); (got > 0) != tt.wantMismatch {
+				t.Errorf("mismatches = %d, want mismatch %v", got, tt.wantMismatch)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"encoding/binary"
+	"unsafe"
+)
+
+// SizeModel is the number of bytes each data structure occupies in a representation.
+type SizeModel struct {
+	Hash      int // branch hash value.
+	BranchID  int // branch id used as key.
+	BlockHash int // block hash used as key.
+	TXOKey    int // leaf index used as key of TXO set.
+	TXO       int
+	Header    int // block header.
+	Bool      int // value of set implemented as map.
+	Pointer   int
+	Map       int // fixed cost of a map.
+	MapEntry  int // additional cost of each map entry.
+}
+
+// SerializedSize is the size model of binary encoding in which each map is
+// a length prefix followed by its entries.
+var SerializedSize = SizeModel{
+	Hash:      32,
+	BranchID:  binary.Size(BranchID{}),
+	BlockHash: 32,
+	TXOKey:    0, // TXO contains its index.
+	TXO:       binary.Size(TXO{}),
+	Header:    binary.Size(Block{}),
+	Bool:      0,
+	Pointer:   0,
+	Map:       4,
+	MapEntry:  0,
+}
+
+// InMemorySize is the size model of Go runtime.
+// Map costs are approximations of hmap header and bucket overhead at average load factor.
+var InMemorySize = SizeModel{
+	Hash:      32,
+	BranchID:  int(unsafe.Sizeof(BranchID{})),
+	BlockHash: 32,
+	TXOKey:    32,
+	TXO:       int(unsafe.Sizeof(TXO{})),
+	Header:    int(unsafe.Sizeof(Block{})),
+	Bool:      1,
+	Pointer:   int(unsafe.Sizeof(uintptr(0))),
+	Map:       48,
+	MapEntry:  4,
+}
+
+// TXOSetBytes returns size of a set of TXOs.
+func (m SizeModel) TXOSetBytes(txos int) int {
+	return m.Map + txos*(m.MapEntry+m.TXOKey+m.Pointer+m.TXO)
+}
+
+// BranchUpdatesBytes returns size of update history of branches.
+// Each update is block hash of the update and the updated branch hash value.
+func (m SizeModel) BranchUpdatesBytes(branches int, updates int) int {
+	return m.Map + branches*(m.MapEntry+m.BranchID+m.Pointer+m.Map) + updates*(m.MapEntry+m.BlockHash+m.Hash)
+}
+
+// HeadersBytes returns size of block headers and its hash keys.
+func (m SizeModel) HeadersBytes(headers int) int {
+	return m.Map + headers*(m.MapEntry+m.BlockHash+m.Bool+m.Header)
+}
+
+// ClientBytes is byte size of each data structure of a client.
+type ClientBytes struct {
+	Unused  int `json:"unused"`
+	Used    int `json:"used"`
+	Memory  int `json:"memory"`
+	Archive int `json:"archive"`
+	Headers int `json:"headers"`
+	TXOs    int `json:"txos"`
+	History int `json:"history"`
+}
+
+// Bytes returns byte size of client's data structures in the size model.
+func (c Client) Bytes(m SizeModel) ClientBytes {
+	var size ClientBytes
+	size.Unused = m.Map
+	for _, txos := range c.Unused {
+		size.Unused += m.MapEntry + m.BlockHash + m.Pointer + m.TXOSetBytes(len(txos))
+	}
+	size.Used = m.Map
+	for _, txos := range c.Used {
+		size.Used += m.MapEntry + m.BlockHash + m.Pointer + m.TXOSetBytes(len(txos))
+	}
+	size.Memory = m.BranchUpdatesBytes(len(c.Memory), c.MemorySize())
+	size.Archive = m.BranchUpdatesBytes(len(c.Archive), c.ArchiveSize())
+	size.Headers = m.HeadersBytes(len(c.Blocks))
+	size.TXOs = len(c.TXOs) * (m.Pointer + m.TXO)
+	size.History = c.History.Bytes(m)
+	return size
+}
+
+// FullNodeBytes is byte size of each data structure of a full node.
+type FullNodeBytes struct {
+	Branches int `json:"branches"`
+	Headers  int `json:"headers"`
+}
+
+// FullNodeStorage returns byte size of data a full node stores in the size model.
+func FullNodeStorage(m SizeModel) FullNodeBytes {
+	updates := 0
+	for _, branch := range Branches {
+		updates += len(branch.Log)
+	}
+	return FullNodeBytes{
+		Branches: m.BranchUpdatesBytes(len(Branches), updates),
+		Headers:  m.HeadersBytes(len(Blocks)),
+	}
+}
@@ -0,0 +1,39 @@
+package models
+
+import "testing"
+
+func TestSerializedSize(t *testing.T) {
+	want := SizeModel{
+		Hash:      32,
+		BranchID:  1 + 32, // height and index.
+		BlockHash: 32,
+		TXOKey:    0,
+		TXO:       32 + 32 + 4 + 8,                // index, parent block hash, owner address and balance.
+		Header:    32 + 8 + 32 + 32 + 32 + 255*32, // parent, height, root, rightmost index, hash and proof.
+		Bool:      0,
+		Pointer:   0,
+		Map:       4,
+		MapEntry:  0,
+	}
+	if SerializedSize != want {
+		t.Errorf("SerializedSize = %+v, want %+v", SerializedSize, want)
+	}
+
+	tests := []struct {
+		name string
+		got  int
+		want int
+	}{
+		{"empty TXO set", SerializedSize.TXOSetBytes(0), 4},
+		{"TXO set", SerializedSize.TXOSetBytes(3), 4 + 3*76},
+		{"branch updates", SerializedSize.BranchUpdatesBytes(2, 5), 4 + 2*(33+4) + 5*(32+32)},
+		{"headers", SerializedSize.HeadersBytes(2), 4 + 2*(32+8296)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("size = %d, want %d", tt.got, tt.want)
+			}
+		})
+	}
+}
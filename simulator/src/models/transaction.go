@@ -1,15 +1,124 @@
 package models
 
 import (
+	"encoding/binary"
 	"errors"
 	"trail_simulator/simulator/src/setting"
 )
 
+var (
+	// TransactionHeaderSize is encoded size of block hash and numbers of inputs and outputs.
+	TransactionHeaderSize = 32 + 4 + 4
+	// InputSize is encoded size of an input TXO and its Merkle proof.
+	InputSize = binary.Size(TXO{}) + binary.Size([255][32]byte{})
+	// OutputSize is encoded size of an output TXO, which is owner address and balance.
+	OutputSize = 4 + 8
+)
+
 // Transaction represents transfer of balance.
 type Transaction struct {
-	BlockHash [32]byte
-	Inputs    []*Proof // input TXOs and its Merkle proof.
-	Outputs   []*TXO   // ouput TXOs.
+	BlockHash  [32]byte
+	Inputs     []*Proof    // input TXOs and its Merkle proof.
+	Outputs    []*TXO      // ouput TXOs.
+	Multiproof *Multiproof // proves inputs together instead of proofs in Inputs. nil if not used.
+}
+
+// newTransaction returns transaction, whose inputs are proved by a multiproof if setting.Multiproof is enabled.
+func newTransaction(blockHash [32]byte, inputs []*Proof, outputs []*TXO) (*Transaction, error) {
+	tx := &Transaction{BlockHash: blockHash, Inputs: inputs, Outputs: outputs}
+	if setting.Multiproof {
+		multiproof, err := NewMultiproof(inputs)
+		if err != nil {
+			return nil, err
+		}
+		tx.Multiproof = multiproof
+	}
+	return tx, nil
+}
+
+// InputTXOs returns TXOs transaction spends.
+func (tx *Transaction) InputTXOs() []*TXO {
+	var txos []*TXO
+	for _, proof := range tx.Inputs {
+		txos = append(txos, proof.TXO)
+	}
+	return txos
+}
+
+// Fee returns total balance of inputs minus total balance of outputs.
+// Fee is 0 if outputs exceed inputs.
+func (tx *Transaction) Fee() uint64 {
+	inputBalance := uint64(0)
+	for _, proof := range tx.Inputs {
+		inputBalance += proof.TXO.Balance
+	}
+	outputBalance := uint64(0)
+	for _, txo := range tx.Outputs {
+		outputBalance += txo.Balance
+	}
+	if inputBalance < outputBalance {
+		return 0
+	}
+	return inputBalance - outputBalance
+}
+
+// Size returns encoded size of transaction in bytes.
+func (tx *Transaction) Size() int {
+	if tx.Multiproof != nil {
+		return TransactionHeaderSize + tx.Multiproof.Size() + len(tx.Outputs)*OutputSize
+	}
+	return TransactionSize(len(tx.Inputs), len(tx.Outputs))
+}
+
+// ProofBytes returns bytes of Merkle proofs of inputs.
+func (tx *Transaction) ProofBytes() int {
+	if tx.Multiproof != nil {
+		return tx.Multiproof.ProofBytes()
+	}
+	return len(tx.Inputs) * binary.Size([255][32]byte{})
+}
+
+// FeeRate returns fee per byte.
+func (tx *Transaction) FeeRate() float64 {
+	return float64(tx.Fee()) / float64(tx.Size())
+}
+
+// RequiredFee returns the minimum fee nodes accept for the transaction.
+// Transactions with multiproof are smaller, so RequiredFee of the numbers of inputs and outputs is its upper bound.
+func (tx *Transaction) RequiredFee() uint64 {
+	return feeOfSize(tx.Size(), len(tx.Inputs), len(tx.Outputs))
+}
+
+// MultiproofMatchesInputs reports whether multiproof proves exactly the TXOs of inputs in the same order.
+// It is true if transaction has no multiproof.
+func (tx *Transaction) MultiproofMatchesInputs() bool {
+	if tx.Multiproof == nil {
+		return true
+	}
+	if len(tx.Multiproof.TXOs) != len(tx.Inputs) {
+		return false
+	}
+	for i, txo := range tx.Multiproof.TXOs {
+		if *txo != *tx.Inputs[i].TXO {
+			return false
+		}
+	}
+	return true
+}
+
+// TransactionSize returns encoded size of transaction which has the numbers of inputs and outputs.
+func TransactionSize(inputs int, outputs int) int {
+	return TransactionHeaderSize + inputs*InputSize + outputs*OutputSize
+}
+
+// RequiredFee returns the minimum fee of transaction which has the numbers of inputs and outputs.
+func RequiredFee(inputs int, outputs int) uint64 {
+	return feeOfSize(TransactionSize(inputs, outputs), inputs, outputs)
+}
+
+// feeOfSize returns the minimum fee of transaction of the size which has the numbers of inputs and outputs.
+func feeOfSize(size int, inputs int, outputs int) uint64 {
+	return uint64(size*setting.FeePerByte + inputs*setting.FeePerTXO + outputs*setting.FeePerOutput)
 }
 
 // BuildTransaction returns a transaction between two clients.
@@ -39,16 +148,14 @@ func BuildTransaction(a *Client, b *Client) (*Transaction, error) {
 		inputs = append(inputs, proof)
 	}
 
-	if totalBalance < uint64(len(inputs)*setting.FeePerTXO) {
+	fee := RequiredFee(len(inputs), 2)
+	if totalBalance < fee {
 		return nil, errors.New("BuildTransaction: cant pay transaction fee")
 	}
 
-	outputBalance := totalBalance - uint64(len(inputs)*setting.FeePerTXO)
+	outputBalance := totalBalance - fee
 	output1 := NewTXOWithoutIndex(a.HeadBlock, a.Address, outputBalance/2)
 	output2 := NewTXOWithoutIndex(b.HeadBlock, b.Address, outputBalance-outputBalance/2)
 
-	return &Transaction{
-		BlockHash: a.HeadBlock,
-		Inputs:    inputs,
-		Outputs:   []*TXO{output1, output2}}, nil
+	return newTransaction(a.HeadBlock, inputs, []*TXO{output1, output2})
 }
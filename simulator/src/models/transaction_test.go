@@ -0,0 +1,98 @@
+package models
+
+import (
+	"testing"
+	"trail_simulator/simulator/src/setting"
+	"trail_simulator/simulator/src/types"
+)
+
+// sizedTx returns transaction with the numbers of inputs and outputs, whose inputs are at adjacent leaves.
+func sizedTx(inputs int, outputs int, multiproof bool) *Transaction {
+	tx := &Transaction{}
+	for i := 0; i < inputs; i++ {
+		txo := NewTXOWithoutIndex(NullHash[0], 0, 1000)
+		txo.SetIndex(types.FromUint64(uint64(i)))
+		tx.Inputs = append(tx.Inputs, NewProof(txo, [255][32]byte{}))
+	}
+	for i := 0; i < outputs; i++ {
+		tx.Outputs = append(tx.Outputs, NewTXOWithoutIndex(NullHash[0], 0, 1))
+	}
+	if multiproof {
+		tx.Multiproof, _ = NewMultiproof(tx.Inputs)
+	}
+	return tx
+}
+
+func TestTransaction_Size(t *testing.T) {
+	const (
+		header = 32 + 4 + 4      // block hash and numbers of inputs and outputs.
+		txo    = 32 + 32 + 4 + 8 // index, parent block hash, owner address and balance.
+		input  = txo + 255*32    // TXO and its Merkle proof.
+		output = 4 + 8           // owner address and balance.
+	)
+	tests := []struct {
+		name       string
+		inputs     int
+		outputs    int
+		multiproof bool
+		want       int
+	}{
+		{"no inputs and outputs", 0, 0, false, header},
+		{"one input and output", 1, 1, false, header + input + output},
+		{"two inputs and three outputs", 2, 3, false, header + 2*input + 3*output},
+		// adjacent TXOs are siblings of each other, and share siblings above.
+		{"multiproof of one input", 1, 1, true, header + txo + 255*32 + output},
+		{"multiproof of two adjacent inputs", 2, 1, true, header + 2*txo + 254*32 + output},
+		{"multiproof of four adjacent inputs", 4, 2, true, header + 4*txo + 253*32 + 2*output},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := sizedTx(tt.inputs, tt.outputs, tt.multiproof)
+			if got := tx.Size(); got != tt.want {
+				t.Errorf("Transaction.Size() = %d, want %d", got, tt.want)
+			}
+			if !tt.multiproof {
+				if got := TransactionSize(tt.inputs, tt.outputs); got != tt.want {
+					t.Errorf("TransactionSize(%d, %d) = %d, want %d", tt.inputs, tt.outputs, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRequiredFee(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		inputs  int
+		outputs int
+		want    int
+	}{
+		{"nothing", 0, 0, 0, 0},
+		{"bytes", 1000, 0, 0, 1000 * setting.FeePerByte},
+		{"TXOs", 0, 3, 0, 3 * setting.FeePerTXO},
+		{"outputs", 0, 0, 4, 4 * setting.FeePerOutput},
+		{"all components", 1000, 3, 4, 1000*setting.FeePerByte + 3*setting.FeePerTXO + 4*setting.FeePerOutput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := feeOfSize(tt.size, tt.inputs, tt.outputs); got != uint64(tt.want) {
+				t.Errorf("feeOfSize(%d, %d, %d) = %d, want %d", tt.size, tt.inputs, tt.outputs, got, tt.want)
+			}
+		})
+	}
+
+	for _, shape := range []struct{ inputs, outputs int }{{1, 1}, {2, 3}} {
+		want := feeOfSize(TransactionSize(shape.inputs, shape.outputs), shape.inputs, shape.outputs)
+		if got := RequiredFee(shape.inputs, shape.outputs); got != want {
+			t.Errorf("RequiredFee(%d, %d) = %d, want %d", shape.inputs, shape.outputs, got, want)
+		}
+		if got := sizedTx(shape.inputs, shape.outputs, false).RequiredFee(); got != want {
+			t.Errorf("Transaction.RequiredFee() of %d inputs and %d outputs = %d, want %d", shape.inputs, shape.outputs, got, want)
+		}
+		// multiproof makes transaction smaller, so required fee is lower.
+		if got := sizedTx(shape.inputs, shape.outputs, true).RequiredFee(); shape.inputs > 1 && got >= want {
+			t.Errorf("Transaction.RequiredFee() with multiproof of %d inputs = %d, want less than %d", shape.inputs, got, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package models
+
+import "crypto/sha256"
+
+// verifiedNode is a node on the path of a proof verified before.
+type verifiedNode struct {
+	hash   [32]byte
+	proofs *[255][32]byte // siblings of the verified proof, which give the path above the node.
+}
+
+// BatchVerifier verifies proofs against a root in one pass over a shared partial tree.
+// A proof whose path reaches a node of a verified path with the same hash and the same siblings above it
+// has the same root, so hashing stops there. Results are identical to Proof.Root.
+type BatchVerifier struct {
+	root   [32]byte
+	nodes  map[BranchID]verifiedNode
+	Hashes int // hashes computed.
+}
+
+// NewBatchVerifier provides batch verifier of proofs against the root.
+func NewBatchVerifier(root [32]byte) *BatchVerifier {
+	return &BatchVerifier{root: root, nodes: map[BranchID]verifiedNode{}}
+}
+
+// Verify returns whether the root calculated from the proof is the root of verifier.
+func (v *BatchVerifier) Verify(proof *Proof, isUsed bool) bool {
+	hash := proof.TXO.Hash(isUsed)
+	v.Hashes++
+	var path []BranchID
+	var hashes [][32]byte
+	joined := false
+	for position := LeafPosition(proof.TXO.Index); !position.IsRoot(); position = position.Parent() {
+		id := position.BranchID()
+		if node, exists := v.nodes[id]; exists && node.hash == hash {
+			if sameAbove(&proof.Proofs, node.proofs, position.Height) {
+				joined = true
+				break
+			}
+		}
+		path = append(path, id)
+		hashes = append(hashes, hash)
+		if position.IsLeft() {
+			hash = sha256.Sum256(append(hash[:], proof.Proofs[position.Height][:]...))
+		} else {
+			hash = sha256.Sum256(append(proof.Proofs[position.Height][:], hash[:]...))
+		}
+		v.Hashes++
+	}
+	if !joined && hash != v.root {
+		return false
+	}
+	proofs := proof.Proofs
+	for i, id := range path {
+		v.nodes[id] = verifiedNode{hashes[i], &proofs}
+	}
+	return true
+}
+
+// VerifyMultiproof returns whether the root calculated from the multiproof is the root of verifier.
+func (v *BatchVerifier) VerifyMultiproof(m *Multiproof, isUsed bool) bool {
+	nodes, root, err := m.nodes(isUsed)
+	if err != nil {
+		return false
+	}
+	// nodes on the paths of TXOs and the root are hashed once each.
+	v.Hashes += len(nodes) + 1
+	return root == v.root
+}
+
+// sameAbove returns whether siblings at and above the height are the same.
+func sameAbove(a *[255][32]byte, b *[255][32]byte, height uint8) bool {
+	for h := int(height); h < len(a); h++ {
+		if a[h] != b[h] {
+			return false
+		}
+	}
+	return true
+}
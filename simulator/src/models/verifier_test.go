@@ -0,0 +1,116 @@
+package models
+
+import "testing"
+
+func TestBatchVerifier_Verify(t *testing.T) {
+	defer resetGlobals()()
+	reference, blockHash, txos, root := spacedTXOs()
+
+	tamperedSibling := referenceProof(reference, blockHash, txos[2])
+	tamperedSibling.Proofs[0] = [32]byte{1}
+	tamperedUpper := referenceProof(reference, blockHash, txos[3])
+	tamperedUpper.Proofs[200] = [32]byte{1}
+	tamperedTXO := referenceProof(reference, blockHash, txos[4])
+	tamperedTXO.TXO = NewTXOWithoutIndex(NullHash[0], 100, 1000)
+	tamperedTXO.TXO.SetIndex(txos[4].Index)
+
+	// proofs are verified in order, so the tampered ones meet paths verified before.
+	proofs := []*Proof{
+		referenceProof(reference, blockHash, txos[0]),
+		referenceProof(reference, blockHash, txos[1]),
+		tamperedSibling,
+		tamperedUpper,
+		tamperedTXO,
+		referenceProof(reference, blockHash, txos[9]),
+		referenceProof(reference, blockHash, txos[1]),
+	}
+	verifier := NewBatchVerifier(root)
+	for i, proof := range proofs {
+		want := proof.Root(false) == root
+		if got := verifier.Verify(proof, false); got != want {
+			t.Errorf("BatchVerifier.Verify() of proof %d = %v, want %v", i, got, want)
+		}
+	}
+	// the first proof and the tampered ones are hashed up to the root,
+	// and the other valid ones stop at their common ancestor with a verified path below height 5.
+	if want := 4*(RootHeight+1) + 3*5; verifier.Hashes > want {
+		t.Errorf("BatchVerifier computed %d hashes, want at most %d", verifier.Hashes, want)
+	}
+
+	t.Run("multiproof", func(t *testing.T) {
+		m, err := NewMultiproof(referenceProofs(reference, blockHash, txos))
+		if err != nil {
+			t.Fatal(err)
+		}
+		verifier := NewBatchVerifier(root)
+		if !verifier.VerifyMultiproof(m, false) {
+			t.Error("BatchVerifier.VerifyMultiproof() failed with valid multiproof")
+		}
+		// ancestors of TXOs at every third leaf are 10, 10, 7, 4 and 2 nodes at heights 0 to 4,
+		// and a node at each height from 5 to the root.
+		if want := 10 + 10 + 7 + 4 + 2 + (RootHeight - 4); verifier.Hashes != want {
+			t.Errorf("BatchVerifier computed %d hashes, want %d", verifier.Hashes, want)
+		}
+		for id := range m.Siblings {
+			m.Siblings[id] = [32]byte{1}
+			break
+		}
+		if verifier.VerifyMultiproof(m, false) {
+			t.Error("BatchVerifier.VerifyMultiproof() succeeded with tampered sibling")
+		}
+	})
+	t.Run("wrong root", func(t *testing.T) {
+		verifier := NewBatchVerifier([32]byte{1})
+		if verifier.Verify(referenceProof(reference, blockHash, txos[0]), false) {
+			t.Error("BatchVerifier.Verify() succeeded against another root")
+		}
+	})
+}
+
+// BenchmarkValidateTransactions compares verification of input proofs one by one and in batch,
+// with transactions spending 400 of 1000 TXOs in genesis.
+func BenchmarkValidateTransactions(b *testing.B) {
+	defer resetGlobals()()
+	node := NewNode(0, NewClient(0))
+	var genesisTXOs []*TXO
+	for i := 0; i < 1000; i++ {
+		genesisTXOs = append(genesisTXOs, NewTXOWithoutIndex(NullHash[0], uint32(i), 1000000))
+	}
+	branches, newTXOs, usedTXOs, block := node.BuildGenesis(NullHash[0], genesisTXOs)
+	head := block.Hash()
+	StoreBlock(block, branches)
+	reference := NewReferenceTree()
+	reference.AddBlock(head, newTXOs, usedTXOs)
+	proofs := referenceProofs(reference, head, newTXOs[:400])
+	var txs []*Transaction
+	for _, proof := range proofs {
+		output := NewTXOWithoutIndex(head, proof.TXO.OwnerAddress, proof.TXO.Balance-RequiredFee(1, 1))
+		txs = append(txs, &Transaction{BlockHash: head, Inputs: []*Proof{proof}, Outputs: []*TXO{output}})
+	}
+
+	b.Run("per-proof", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, proof := range proofs {
+				if proof.Root(false) != block.Root {
+					b.Fatal("proof doesn't verify")
+				}
+			}
+		}
+	})
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			verifier := NewBatchVerifier(block.Root)
+			for _, proof := range proofs {
+				if !verifier.Verify(proof, false) {
+					b.Fatal("proof doesn't verify")
+				}
+			}
+		}
+	})
+	b.Run("validate", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			node.Stats = BuildStats{}
+			node.validateTransactions(txs, head, *block)
+		}
+	})
+}
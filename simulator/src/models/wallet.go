@@ -0,0 +1,376 @@
+package models
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"trail_simulator/simulator/src/types"
+)
+
+// maxBranchAndBoundTries is the number of subsets branch-and-bound selection examines before giving up.
+const maxBranchAndBoundTries = 100000
+
+// FeeFunc returns fee of transaction which has the numbers of inputs and outputs.
+type FeeFunc func(inputs int, outputs int) uint64
+
+// CoinSelector selects TXOs client spends for a payment.
+type CoinSelector interface {
+	// Select returns unused TXOs of client at its head block whose total balance covers amount and fee.
+	Select(c *Client, amount uint64, fee FeeFunc) ([]*TXO, error)
+}
+
+type allSelector struct{}
+type largestFirstSelector struct{}
+type smallestFirstSelector struct{}
+type randomSelector struct{}
+type branchAndBoundSelector struct{}
+type localitySelector struct{}
+
+// NewCoinSelector returns coin selector of the strategy.
+// Strategy is one of "all", "largest-first", "smallest-first", "random", "branch-and-bound" and "locality".
+func NewCoinSelector(strategy string) (CoinSelector, error) {
+	switch strategy {
+	case "all":
+		return allSelector{}, nil
+	case "largest-first":
+		return largestFirstSelector{}, nil
+	case "smallest-first":
+		return smallestFirstSelector{}, nil
+	case "random":
+		return randomSelector{}, nil
+	case "branch-and-bound":
+		return branchAndBoundSelector{}, nil
+	case "locality":
+		return localitySelector{}, nil
+	}
+	return nil, errors.New("NewCoinSelector: unknown strategy " + strategy)
+}
+
+// unusedTXOs returns unused TXOs of client at its head block in descending order of balance.
+func unusedTXOs(c *Client) []*TXO {
+	var txos []*TXO
+	for _, txo := range c.Unused[c.HeadBlock] {
+		txos = append(txos, txo)
+	}
+	sort.Slice(txos, func(i, j int) bool { return txos[i].Balance > txos[j].Balance })
+	return txos
+}
+
+// covers returns whether TXOs pay amount and fee of transaction with or without change output.
+func covers(total uint64, inputs int, amount uint64, fee FeeFunc) bool {
+	return total >= amount+fee(inputs, 1)
+}
+
+// selectInOrder selects TXOs from the head of txos until they pay amount, fee and change output.
+func selectInOrder(txos []*TXO, amount uint64, fee FeeFunc) ([]*TXO, error) {
+	total := uint64(0)
+	for i, txo := range txos {
+		total += txo.Balance
+		if total >= amount+fee(i+1, 2) {
+			return txos[:i+1], nil
+		}
+	}
+	if covers(total, len(txos), amount, fee) {
+		return txos, nil
+	}
+	return nil, errors.New("CoinSelector: insufficient balance")
+}
+
+func (s allSelector) Select(c *Client, amount uint64, fee FeeFunc) ([]*TXO, error) {
+	txos := unusedTXOs(c)
+	total := uint64(0)
+	for _, txo := range txos {
+		total += txo.Balance
+	}
+	if !covers(total, len(txos), amount, fee) {
+		return nil, errors.New("CoinSelector: insufficient balance")
+	}
+	return txos, nil
+}
+
+func (s largestFirstSelector) Select(c *Client, amount uint64, fee FeeFunc) ([]*TXO, error) {
+	return selectInOrder(unusedTXOs(c), amount, fee)
+}
+
+func (s smallestFirstSelector) Select(c *Client, amount uint64, fee FeeFunc) ([]*TXO, error) {
+	txos := unusedTXOs(c)
+	for i, j := 0, len(txos)-1; i < j; i, j = i+1, j-1 {
+		txos[i], txos[j] = txos[j], txos[i]
+	}
+	return selectInOrder(txos, amount, fee)
+}
+
+func (s randomSelector) Select(c *Client, amount uint64, fee FeeFunc) ([]*TXO, error) {
+	txos := unusedTXOs(c)
+	rand.Shuffle(len(txos), func(i, j int) { txos[i], txos[j] = txos[j], txos[i] })
+	return selectInOrder(txos, amount, fee)
+}
+
+// Select searches TXOs which pay amount and fee without change output.
+// The excess must be less than the fee of change output, which is paid as fee.
+// If there is no such TXOs, it selects TXOs in largest-first order.
+func (s branchAndBoundSelector) Select(c *Client, amount uint64, fee FeeFunc) ([]*TXO, error) {
+	txos := unusedTXOs(c)
+	remaining := make([]uint64, len(txos)+1) // total balance of txos[i:].
+	for i := len(txos) - 1; i >= 0; i-- {
+		remaining[i] = remaining[i+1] + txos[i].Balance
+	}
+
+	tries := 0
+	var selected []int
+	var search func(i int, total uint64) bool
+	search = func(i int, total uint64) bool {
+		tries++
+		n := len(selected)
+		if n > 0 {
+			target := amount + fee(n, 1)
+			if total >= target && total < amount+fee(n, 2) {
+				return true
+			}
+			if total >= target {
+				return false
+			}
+		}
+		if i == len(txos) || tries > maxBranchAndBoundTries || total+remaining[i] < amount+fee(n+1, 1) {
+			return false
+		}
+		selected = append(selected, i)
+		if search(i+1, total+txos[i].Balance) {
+			return true
+		}
+		selected = selected[:n]
+		return search(i+1, total)
+	}
+	if search(0, 0) {
+		var result []*TXO
+		for _, i := range selected {
+			result = append(result, txos[i])
+		}
+		return result, nil
+	}
+	return selectInOrder(txos, amount, fee)
+}
+
+// Select greedily adds the TXO which saves the most bytes when spent with selected TXOs,
+// until selected TXOs pay amount, fee and change output. Ties are broken by larger balance.
+// Each proof branch shared with selected TXOs saves a hash, which a multiproof includes once,
+// and each branch no other unused TXO needs frees its updates in memory, a block hash and a hash each.
+func (s localitySelector) Select(c *Client, amount uint64, fee FeeFunc) ([]*TXO, error) {
+	sharedBranchBytes := SerializedSize.Hash
+	updateBytes := SerializedSize.BlockHash + SerializedSize.Hash
+	txos := unusedTXOs(c)
+	proofIDs := make([][255]BranchID, len(txos))
+	references := map[BranchID]int{} // number of unused TXOs whose proofs include the branch.
+	for i, txo := range txos {
+		proofIDs[i] = getProofBranchIDs(txo.Index)
+		for _, proofID := range proofIDs[i] {
+			references[proofID]++
+		}
+	}
+
+	selectedReferences := map[BranchID]int{}
+	isSelected := make([]bool, len(txos))
+	var selected []*TXO
+	total := uint64(0)
+	for len(selected) < len(txos) {
+		best := -1
+		bestScore := -1
+		for i := range txos {
+			if isSelected[i] {
+				continue
+			}
+			score := 0
+			for _, proofID := range proofIDs[i] {
+				if selectedReferences[proofID] > 0 {
+					score += sharedBranchBytes
+				}
+				if selectedReferences[proofID]+1 == references[proofID] {
+					score += len(c.Memory[proofID]) * updateBytes
+				}
+			}
+			if score > bestScore {
+				best = i
+				bestScore = score
+			}
+		}
+		isSelected[best] = true
+		selected = append(selected, txos[best])
+		for _, proofID := range proofIDs[best] {
+			selectedReferences[proofID]++
+		}
+		total += txos[best].Balance
+		if total >= amount+fee(len(selected), 2) {
+			return selected, nil
+		}
+	}
+	if covers(total, len(selected), amount, fee) {
+		return selected, nil
+	}
+	return nil, errors.New("CoinSelector: insufficient balance")
+}
+
+// MemoryFreed returns the number of branch updates in client's memory
+// which are no longer needed after spending the TXOs.
+func MemoryFreed(c *Client, spent []*TXO) int {
+	isSpent := map[types.Uint256]bool{}
+	for _, txo := range spent {
+		isSpent[txo.Index] = true
+	}
+	needed := map[BranchID]bool{}
+	for _, txo := range c.Unused[c.HeadBlock] {
+		if isSpent[txo.Index] {
+			continue
+		}
+		for _, proofID := range getProofBranchIDs(txo.Index) {
+			needed[proofID] = true
+		}
+	}
+	freed := 0
+	for _, txo := range spent {
+		for _, proofID := range getProofBranchIDs(txo.Index) {
+			if !needed[proofID] {
+				freed += len(c.Memory[proofID])
+				needed[proofID] = true // count each branch once.
+			}
+		}
+	}
+	return freed
+}
+
+// BuildPayment returns a transaction by which sender pays amount to receiver.
+// The change is returned to sender as a new TXO if it is larger than the fee of change output.
+func BuildPayment(sender *Client, receiver *Client, amount uint64, selector CoinSelector) (*Transaction, error) {
+	if sender.HeadBlock != receiver.HeadBlock {
+		return nil, errors.New("BuildPayment: clients not follow same block")
+	}
+	if amount == 0 {
+		return nil, errors.New("BuildPayment: amount must be larger than 0")
+	}
+	return BuildMultiPartyTransaction([]Payment{{sender, amount}}, []Payment{{receiver, amount}}, selector)
+}
+
+// Payment is amount a client pays or receives in a transaction.
+type Payment struct {
+	Client *Client
+	Amount uint64
+}
+
+// SenderFee returns fee function the i-th sender of multi-party transaction selects TXOs with.
+// Senders except the first pay no fee. The first sender pays the fee of whole transaction,
+// in which the other senders spend otherInputs TXOs and have change outputs.
+func SenderFee(i int, senders int, recipients int, otherInputs int) FeeFunc {
+	if i > 0 {
+		return func(int, int) uint64 { return 0 }
+	}
+	otherOutputs := recipients + senders - 1
+	return func(inputs int, outputs int) uint64 {
+		return RequiredFee(inputs+otherInputs, outputs-1+otherOutputs)
+	}
+}
+
+// BuildMultiPartyTransaction returns a transaction by which senders pay recipients.
+// Total amount of senders must be equal to total amount of recipients.
+// Each sender spends coins selected by selector and gets its change as a new TXO.
+// Senders except the first select coins with zero fee and pay exactly their amounts,
+// and the first sender pays the fee of whole transaction as SenderFee estimates it.
+func BuildMultiPartyTransaction(senders []Payment, recipients []Payment, selector CoinSelector) (*Transaction, error) {
+	if len(senders) == 0 || len(recipients) == 0 {
+		return nil, errors.New("BuildMultiPartyTransaction: no senders or recipients")
+	}
+	head := senders[0].Client.HeadBlock
+	sent := uint64(0)
+	for _, sender := range senders {
+		if sender.Client.HeadBlock != head {
+			return nil, errors.New("BuildMultiPartyTransaction: clients not follow same block")
+		}
+		sent += sender.Amount
+	}
+	received := uint64(0)
+	for _, recipient := range recipients {
+		if recipient.Client.HeadBlock != head {
+			return nil, errors.New("BuildMultiPartyTransaction: clients not follow same block")
+		}
+		if recipient.Amount == 0 {
+			return nil, errors.New("BuildMultiPartyTransaction: amount must be larger than 0")
+		}
+		received += recipient.Amount
+	}
+	if sent != received {
+		return nil, errors.New("BuildMultiPartyTransaction: senders and recipients amounts differ")
+	}
+
+	selected := make([][]*TXO, len(senders))
+	otherInputs := 0
+	for i := 1; i < len(senders); i++ {
+		txos, err := selector.Select(senders[i].Client, senders[i].Amount, SenderFee(i, len(senders), len(recipients), 0))
+		if err != nil {
+			return nil, err
+		}
+		selected[i] = txos
+		otherInputs += len(txos)
+	}
+	fee := SenderFee(0, len(senders), len(recipients), otherInputs)
+	txos, err := selector.Select(senders[0].Client, senders[0].Amount, fee)
+	if err != nil {
+		return nil, err
+	}
+	selected[0] = txos
+
+	var inputs []*Proof
+	var outputs []*TXO
+	for _, recipient := range recipients {
+		outputs = append(outputs, NewTXOWithoutIndex(head, recipient.Client.Address, recipient.Amount))
+	}
+	for i, sender := range senders {
+		total := uint64(0)
+		for _, txo := range selected[i] {
+			total += txo.Balance
+			proof, err := sender.Client.BuildProof(txo)
+			if err != nil {
+				return nil, err
+			}
+			inputs = append(inputs, proof)
+		}
+		payment := sender.Amount
+		if i == 0 {
+			if total >= payment+fee(len(selected[0]), 2) {
+				payment += fee(len(selected[0]), 2)
+			} else if total >= payment+fee(len(selected[0]), 1) {
+				// the first sender has no change output and the excess is paid as fee.
+				payment = total
+			} else {
+				return nil, errors.New("BuildMultiPartyTransaction: cant pay amount and fee")
+			}
+		}
+		if total < payment {
+			return nil, errors.New("BuildMultiPartyTransaction: cant pay amount and fee")
+		}
+		if total > payment {
+			outputs = append(outputs, NewTXOWithoutIndex(head, sender.Client.Address, total-payment))
+		}
+	}
+	return newTransaction(head, inputs, outputs)
+}
+
+// BuildConsolidation returns a transaction which merges all unused TXOs of client into a TXO.
+func BuildConsolidation(c *Client) (*Transaction, error) {
+	txos := unusedTXOs(c)
+	if len(txos) < 2 {
+		return nil, errors.New("BuildConsolidation: nothing to consolidate")
+	}
+	total := uint64(0)
+	var inputs []*Proof
+	for _, txo := range txos {
+		total += txo.Balance
+		proof, err := c.BuildProof(txo)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, proof)
+	}
+	fee := RequiredFee(len(inputs), 1)
+	if total <= fee {
+		return nil, errors.New("BuildConsolidation: cant pay transaction fee")
+	}
+	return newTransaction(c.HeadBlock, inputs, []*TXO{NewTXOWithoutIndex(c.HeadBlock, c.Address, total-fee)})
+}
@@ -0,0 +1,242 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"trail_simulator/simulator/src/types"
+)
+
+// walletClient returns client which has unused TXOs of the balances at its head block.
+func walletClient(balances ...uint64) *Client {
+	client := NewClient(0)
+	client.HeadBlock = [32]byte{1}
+	client.Unused[client.HeadBlock] = map[types.Uint256]*TXO{}
+	for i, balance := range balances {
+		txo := NewTXOWithoutIndex(NullHash[0], 0, balance)
+		txo.SetIndex(types.FromUint64(uint64(i)))
+		client.Unused[client.HeadBlock][txo.Index] = txo
+	}
+	return client
+}
+
+func TestCoinSelector_Select(t *testing.T) {
+	// change output costs 10 more than a transaction without it.
+	fee := func(inputs int, outputs int) uint64 { return uint64(inputs + 10*outputs) }
+	// transaction without change output must pay exact amount.
+	exactFee := func(inputs int, outputs int) uint64 { return uint64(outputs - 1) }
+	var evenCoins []uint64
+	for i := 0; i < 40; i++ {
+		evenCoins = append(evenCoins, 2)
+	}
+
+	tests := []struct {
+		name     string
+		strategy string
+		balances []uint64
+		amount   uint64
+		fee      FeeFunc
+		want     []uint64 // balances of selected TXOs.
+	}{
+		{
+			name:     "all spends every TXO",
+			strategy: "all",
+			balances: []uint64{30, 100, 50, 60},
+			amount:   75,
+			fee:      fee,
+			want:     []uint64{100, 60, 50, 30},
+		},
+		{
+			name:     "largest-first stops at first TXOs paying change output",
+			strategy: "largest-first",
+			balances: []uint64{30, 100, 50, 60},
+			amount:   75,
+			fee:      fee,
+			want:     []uint64{100},
+		},
+		{
+			name:     "smallest-first stops at first TXOs paying change output",
+			strategy: "smallest-first",
+			balances: []uint64{30, 100, 50, 60},
+			amount:   75,
+			fee:      fee,
+			want:     []uint64{30, 50, 60},
+		},
+		{
+			name:     "smallest-first without change output",
+			strategy: "smallest-first",
+			balances: []uint64{30, 50},
+			amount:   65,
+			fee:      fee,
+			want:     []uint64{30, 50},
+		},
+		{
+			name:     "branch-and-bound finds TXOs without change output",
+			strategy: "branch-and-bound",
+			balances: []uint64{30, 100, 50, 60},
+			amount:   75,
+			fee:      fee,
+			want:     []uint64{60, 30},
+		},
+		{
+			name:     "branch-and-bound accepts excess just below fee of change output",
+			strategy: "branch-and-bound",
+			balances: []uint64{100, 95},
+			amount:   75,
+			fee:      fee,
+			want:     []uint64{95},
+		},
+		{
+			name:     "branch-and-bound falls back to largest-first without exact match",
+			strategy: "branch-and-bound",
+			balances: []uint64{100, 60},
+			amount:   50,
+			fee:      fee,
+			want:     []uint64{100},
+		},
+		{
+			name:     "branch-and-bound falls back to largest-first after try limit",
+			strategy: "branch-and-bound",
+			balances: evenCoins,
+			amount:   31,
+			fee:      exactFee,
+			want:     evenCoins[:16],
+		},
+		{
+			name:     "locality selects largest TXO without shared branches",
+			strategy: "locality",
+			balances: []uint64{30, 100, 50, 60},
+			amount:   75,
+			fee:      fee,
+			want:     []uint64{100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := NewCoinSelector(tt.strategy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			txos, err := selector.Select(walletClient(tt.balances...), tt.amount, tt.fee)
+			if err != nil {
+				t.Fatalf("Select() error = %v", err)
+			}
+			var got []uint64
+			for _, txo := range txos {
+				got = append(got, txo.Balance)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Select() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("insufficient balance", func(t *testing.T) {
+		for _, strategy := range []string{"all", "largest-first", "smallest-first", "random", "branch-and-bound", "locality"} {
+			selector, _ := NewCoinSelector(strategy)
+			if txos, err := selector.Select(walletClient(30, 100, 50, 60), 230, fee); err == nil {
+				t.Errorf("%s Select() = %d TXOs, want error", strategy, len(txos))
+			}
+		}
+	})
+	t.Run("random covers amount and fee", func(t *testing.T) {
+		selector, _ := NewCoinSelector("random")
+		for i := 0; i < 20; i++ {
+			txos, err := selector.Select(walletClient(30, 100, 50, 60), 75, fee)
+			if err != nil {
+				t.Fatal(err)
+			}
+			total := uint64(0)
+			for _, txo := range txos {
+				total += txo.Balance
+			}
+			if !covers(total, len(txos), 75, fee) {
+				t.Errorf("Select() = TXOs of total %d, which don't cover amount and fee", total)
+			}
+		}
+	})
+}
+
+// localityClient returns client which has unused TXOs of the balances at the leaf indexes,
+// and an update of each proof branch of TXOs whose keepsMemory is true in memory.
+func localityClient(indexes []types.Uint256, balances []uint64, keepsMemory []bool) *Client {
+	client := walletClient()
+	for i, index := range indexes {
+		txo := NewTXOWithoutIndex(NullHash[0], 0, balances[i])
+		txo.SetIndex(index)
+		client.Unused[client.HeadBlock][index] = txo
+		if keepsMemory[i] {
+			for _, proofID := range getProofBranchIDs(index) {
+				client.Memory[proofID] = map[[32]byte]bool{{1}: true}
+			}
+		}
+	}
+	return client
+}
+
+func TestLocalitySelector_Select(t *testing.T) {
+	fee := func(inputs int, outputs int) uint64 { return uint64(inputs + 10*outputs) }
+	// TXO at far index shares only branches above height 200 with TXOs at the first leaves.
+	far := types.FromUint64(1).Lsh(200)
+	indexes := []types.Uint256{types.FromUint64(0), types.FromUint64(1), types.FromUint64(2), far}
+	balances := []uint64{100, 20, 60, 30}
+
+	tests := []struct {
+		name        string
+		keepsMemory []bool
+		want        []uint64 // balances of selected TXOs.
+	}{
+		{
+			name:        "adjacent TXO shares the most branches",
+			keepsMemory: []bool{false, false, false, false},
+			want:        []uint64{100, 20},
+		},
+		{
+			name:        "TXO freeing memory first",
+			keepsMemory: []bool{false, false, false, true},
+			want:        []uint64{30, 100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txos, err := localitySelector{}.Select(localityClient(indexes, balances, tt.keepsMemory), 95, fee)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []uint64
+			for _, txo := range txos {
+				got = append(got, txo.Balance)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Select() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryFreed(t *testing.T) {
+	far := types.FromUint64(1).Lsh(200)
+	indexes := []types.Uint256{types.FromUint64(0), types.FromUint64(1), far}
+	client := localityClient(indexes, []uint64{100, 20, 30}, []bool{true, true, true})
+	txos := client.Unused[client.HeadBlock]
+
+	tests := []struct {
+		name  string
+		spent []*TXO
+		want  int
+	}{
+		{"nothing", nil, 0},
+		// proofs of the other TXOs include every branch except leaf 0, the sibling of leaf 1.
+		{"TXO whose other branches are needed", []*TXO{txos[indexes[1]]}, 1},
+		// leaves 0 and 1, and siblings of their ancestors up to height 200.
+		{"adjacent TXOs", []*TXO{txos[indexes[0]], txos[indexes[1]]}, 2 + 200},
+		{"far TXO", []*TXO{txos[far]}, 201},
+		{"every TXO", []*TXO{txos[indexes[0]], txos[indexes[1]], txos[far]}, client.MemorySize()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MemoryFreed(client, tt.spent); got != tt.want {
+				t.Errorf("MemoryFreed() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+	"trail_simulator/simulator/src/helpers"
+	"trail_simulator/simulator/src/models"
+	"trail_simulator/simulator/src/setting"
+	"trail_simulator/simulator/src/workload"
+)
+
+var filePath string
+
+func init() {
+	filePath = "/go/src/trail_simulator/simulator/output/output_" + fmt.Sprint(time.Now().Unix()) + ".json"
+	file, err := os.Create(filePath)
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+	file.WriteString("{")
+	file.WriteString(
+		"\"setting\":" +
+			"{\"number_of_node\":" + fmt.Sprint(setting.NumberOfNode) +
+			",\"number_of_client\":" + fmt.Sprint(setting.NumberOfClient) +
+			",\"end_block_height\":" + fmt.Sprint(setting.EndBlockHeight) +
+			",\"archive_height\":" + fmt.Sprint(setting.ArchiveHeight) +
+			",\"total_balance\":" + fmt.Sprint(setting.TotalBalance) +
+			",\"genesis_file\":" + fmt.Sprintf("%q", setting.GenesisFile) +
+			",\"inputs_per_block\":" + fmt.Sprint(setting.InputsPerBlock) +
+			",\"join_rate\":" + fmt.Sprint(setting.JoinRate) +
+			",\"leave_rate\":" + fmt.Sprint(setting.LeaveRate) +
+			",\"workload\":" + fmt.Sprintf("%q", setting.Workload) +
+			",\"zipf_exponent\":" + fmt.Sprint(setting.ZipfExponent) +
+			",\"merchant_fraction\":" + fmt.Sprint(setting.MerchantFraction) +
+			",\"merchant_share\":" + fmt.Sprint(setting.MerchantShare) +
+			",\"amount_distribution\":" + fmt.Sprintf("%q", setting.AmountDistribution) +
+			",\"amount_mean_fraction\":" + fmt.Sprint(setting.AmountMeanFraction) +
+			",\"amount_pareto_shape\":" + fmt.Sprint(setting.AmountParetoShape) +
+			",\"arrival_pattern\":" + fmt.Sprintf("%q", setting.ArrivalPattern) +
+			",\"arrival_period\":" + fmt.Sprint(setting.ArrivalPeriod) +
+			",\"arrival_amplitude\":" + fmt.Sprint(setting.ArrivalAmplitude) +
+			",\"trace_file\":" + fmt.Sprintf("%q", setting.TraceFile) +
+			",\"trace_block_interval\":" + fmt.Sprint(setting.TraceBlockInterval) +
+			",\"trace_amount_scale\":" + fmt.Sprint(setting.TraceAmountScale) +
+			",\"merge_weight\":" + fmt.Sprint(setting.MergeWeight) +
+			",\"payment_weight\":" + fmt.Sprint(setting.PaymentWeight) +
+			",\"batch_weight\":" + fmt.Sprint(setting.BatchWeight) +
+			",\"multi_sender_weight\":" + fmt.Sprint(setting.MultiSenderWeight) +
+			",\"consolidation_weight\":" + fmt.Sprint(setting.ConsolidationWeight) +
+			",\"batch_recipients\":" + fmt.Sprint(setting.BatchRecipients) +
+			",\"multi_senders\":" + fmt.Sprint(setting.MultiSenders) +
+			",\"coin_selection\":" + fmt.Sprintf("%q", setting.CoinSelection) +
+			",\"index_allocation\":" + fmt.Sprintf("%q", setting.IndexAllocation) +
+			",\"max_block_size\":" + fmt.Sprint(setting.MaxBlockSize) +
+			",\"fee_per_byte\":" + fmt.Sprint(setting.FeePerByte) +
+			",\"fee_per_txo\":" + fmt.Sprint(setting.FeePerTXO) +
+			",\"fee_per_output\":" + fmt.Sprint(setting.FeePerOutput) +
+			",\"proof_validity_blocks\":" + fmt.Sprint(setting.ProofValidityBlocks) +
+			",\"multiproof\":" + fmt.Sprint(setting.Multiproof) +
+			",\"max_mempool_size\":" + fmt.Sprint(setting.MaxMempoolSize) +
+			",\"output_histogram\":" + fmt.Sprint(setting.OutputHistogram) +
+			",\"reference_check\":" + fmt.Sprint(setting.ReferenceCheck) +
+			",\"branch_prune_depth\":" + fmt.Sprint(setting.BranchPruneDepth) +
+			",\"finality_depth\":" + fmt.Sprint(setting.FinalityDepth) +
+			",\"keep_history\":" + fmt.Sprint(setting.KeepHistory) +
+			",\"checkpoint_mode\":" + fmt.Sprintf("%q", setting.CheckpointMode) +
+			",\"checkpoint_depth\":" + fmt.Sprint(setting.CheckpointDepth) +
+			",\"checkpoint_interval\":" + fmt.Sprint(setting.CheckpointInterval) +
+			",\"checkpoint_quorum\":" + fmt.Sprint(setting.CheckpointQuorum) +
+			"},\"blocks\":[")
+}
+
+// clientMetrics is the per client values of a metric at a block.
+type clientMetrics struct {
+	unused  []int
+	used    []int
+	memory  []int
+	archive []int
+	blocks  []int
+	txos    []int
+
+	proofBranches       []int
+	sharedProofBranches []int
+
+	serialized []models.ClientBytes
+	inMemory   []models.ClientBytes
+}
+
+func collectClientMetrics(clients []*models.Client) clientMetrics {
+	var m clientMetrics
+	for _, client := range clients {
+		m.unused = append(m.unused, client.UnusedSize())
+		m.used = append(m.used, client.UsedSize())
+		m.memory = append(m.memory, client.MemorySize())
+		m.archive = append(m.archive, client.ArchiveSize())
+		m.blocks = append(m.blocks, client.BlocksSize())
+		m.txos = append(m.txos, client.TXOsSize())
+		m.proofBranches = append(m.proofBranches, client.ProofBranchesSize())
+		m.sharedProofBranches = append(m.sharedProofBranches, client.SharedProofBranchesSize())
+		m.serialized = append(m.serialized, client.Bytes(models.SerializedSize))
+		m.inMemory = append(m.inMemory, client.Bytes(models.InMemorySize))
+	}
+	return m
+}
+
+// byteStats is statistics of byte size of a data structure in each representation.
+type byteStats struct {
+	Serialized helpers.Stats `json:"serialized"`
+	InMemory   helpers.Stats `json:"in_memory"`
+}
+
+func calcByteStats(serialized []models.ClientBytes, inMemory []models.ClientBytes, field func(models.ClientBytes) int) byteStats {
+	var serializedValues, inMemoryValues []int
+	for i := range serialized {
+		serializedValues = append(serializedValues, field(serialized[i]))
+		inMemoryValues = append(inMemoryValues, field(inMemory[i]))
+	}
+	return byteStats{helpers.CalcStats(serializedValues), helpers.CalcStats(inMemoryValues)}
+}
+
+// fullNodeByteStats is byte size of full node storage in each representation.
+type fullNodeByteStats struct {
+	Serialized models.FullNodeBytes `json:"serialized"`
+	InMemory   models.FullNodeBytes `json:"in_memory"`
+}
+
+// churnStats is clients joined and left at a block.
+type churnStats struct {
+	Joined           int                    `json:"joined"`
+	Left             int                    `json:"left"`
+	AbandonedTXOs    int                    `json:"abandoned_txos"` // unused TXOs of clients left.
+	AbandonedBalance uint64                 `json:"abandoned_balance"`
+	Bootstrap        []models.BootstrapCost `json:"bootstrap"` // of each client joined.
+}
+
+// blockStats is values measured while processing a block.
+type blockStats struct {
+	PrunedBranchLogEntries int                   `json:"pruned_branch_log_entries"`
+	PrunedClientData       models.PruneResult    `json:"pruned_client_data"`
+	PrunedForkData         models.PrunedForkData `json:"pruned_fork_data"`
+	Mempool                models.MempoolStats   `json:"mempool"` // of the node generated the block.
+	Build                  models.BuildStats     `json:"build"`
+	Workload               workload.Stats        `json:"workload"`
+	Churn                  churnStats            `json:"churn"`
+	Violations             []models.Violation    `json:"violations"` // broken invariants after the block.
+}
+
+// blockRecord is output data of a block.
+type blockRecord struct {
+	Height                 uint64                 `json:"height"`
+	BlockHash              string                 `json:"block_hash"`
+	NumberOfUpdatedBranchs int                    `json:"number_of_updated_branchs"`
+	NumberOfNewUTXO        int                    `json:"number_of_new_utxo"`
+	NumberOfUsedUTXO       int                    `json:"number_of_used_utxo"`
+	ActiveClients          int                    `json:"active_clients"` // clients joined and not left.
+	MaxUnused              int                    `json:"max_unused"`
+	MaxUsed                int                    `json:"max_used"`
+	MaxMemory              int                    `json:"max_memory"`
+	MaxArchive             int                    `json:"max_archiive"`
+	Unused                 helpers.Stats          `json:"unused"`
+	Used                   helpers.Stats          `json:"used"`
+	Memory                 helpers.Stats          `json:"memory"`
+	Archive                helpers.Stats          `json:"archive"`
+	Blocks                 helpers.Stats          `json:"blocks"`
+	TXOs                   helpers.Stats          `json:"txos"`
+	ProofBranches          helpers.Stats          `json:"proof_branches"`
+	SharedProofBranches    helpers.Stats          `json:"shared_proof_branches"` // proof branches shared by unused TXOs.
+	UnusedHistogram        []helpers.HistogramBin `json:"unused_histogram,omitempty"`
+	UsedHistogram          []helpers.HistogramBin `json:"used_histogram,omitempty"`
+	MemoryHistogram        []helpers.HistogramBin `json:"memory_histogram,omitempty"`
+	ArchiveHistogram       []helpers.HistogramBin `json:"archive_histogram,omitempty"`
+	UnusedBytes            byteStats              `json:"unused_bytes"`
+	UsedBytes              byteStats              `json:"used_bytes"`
+	MemoryBytes            byteStats              `json:"memory_bytes"`
+	ArchiveBytes           byteStats              `json:"archive_bytes"`
+	HeadersBytes           byteStats              `json:"headers_bytes"`
+	TXOsBytes              byteStats              `json:"txos_bytes"`
+	HistoryBytes           byteStats              `json:"history_bytes"`
+	FullNodeBytes          fullNodeByteStats      `json:"full_node_bytes"`
+	BranchLogEntries       int                    `json:"branch_log_entries"`
+	FinalizedHeight        uint64                 `json:"finalized_height"`
+	Checkpoints            int                    `json:"checkpoints"`
+	RejectedBlocks         int                    `json:"rejected_blocks"`
+	MempoolSize            helpers.Stats          `json:"mempool_size"`
+	InclusionLatency       helpers.Stats          `json:"inclusion_latency"`
+	blockStats
+}
+
+func outputBlockData(clients []*models.Client, nodes []*models.Node, block models.Block, branchIDs map[models.BranchID]bool, newTXOs []*models.TXO, usedTXOs []*models.TXO, stats blockStats) {
+	metrics := collectClientMetrics(clients)
+	blockHash := block.Hash()
+	blockHashStr := models.ShortHashString(blockHash)
+
+	record := blockRecord{
+		blockStats:             stats,
+		Height:                 block.Height,
+		BlockHash:              blockHashStr,
+		NumberOfUpdatedBranchs: len(branchIDs),
+		NumberOfNewUTXO:        len(newTXOs),
+		NumberOfUsedUTXO:       len(usedTXOs),
+		ActiveClients:          len(clients),
+		Unused:                 helpers.CalcStats(metrics.unused),
+		Used:                   helpers.CalcStats(metrics.used),
+		Memory:                 helpers.CalcStats(metrics.memory),
+		Archive:                helpers.CalcStats(metrics.archive),
+		Blocks:                 helpers.CalcStats(metrics.blocks),
+		TXOs:                   helpers.CalcStats(metrics.txos),
+		ProofBranches:          helpers.CalcStats(metrics.proofBranches),
+		SharedProofBranches:    helpers.CalcStats(metrics.sharedProofBranches),
+		UnusedBytes:            calcByteStats(metrics.serialized, metrics.inMemory, func(b models.ClientBytes) int { return b.Unused }),
+		UsedBytes:              calcByteStats(metrics.serialized, metrics.inMemory, func(b models.ClientBytes) int { return b.Used }),
+		MemoryBytes:            calcByteStats(metrics.serialized, metrics.inMemory, func(b models.ClientBytes) int { return b.Memory }),
+		ArchiveBytes:           calcByteStats(metrics.serialized, metrics.inMemory, func(b models.ClientBytes) int { return b.Archive }),
+		HeadersBytes:           calcByteStats(metrics.serialized, metrics.inMemory, func(b models.ClientBytes) int { return b.Headers }),
+		TXOsBytes:              calcByteStats(metrics.serialized, metrics.inMemory, func(b models.ClientBytes) int { return b.TXOs }),
+		HistoryBytes:           calcByteStats(metrics.serialized, metrics.inMemory, func(b models.ClientBytes) int { return b.History }),
+		FullNodeBytes: fullNodeByteStats{
+			Serialized: models.FullNodeStorage(models.SerializedSize),
+			InMemory:   models.FullNodeStorage(models.InMemorySize),
+		},
+		BranchLogEntries: models.BranchLogEntries(),
+		Checkpoints:      len(models.Checkpoints),
+	}
+	if checkpoint := models.LatestCheckpoint(); checkpoint != nil {
+		record.FinalizedHeight = checkpoint.Height
+	}
+	for _, client := range clients {
+		record.RejectedBlocks += client.Rejected
+	}
+	var mempoolSizes []int
+	for _, node := range nodes {
+		mempoolSizes = append(mempoolSizes, node.Mempool.Size())
+	}
+	record.MempoolSize = helpers.CalcStats(mempoolSizes)
+	record.InclusionLatency = helpers.CalcStats(stats.Mempool.Latencies)
+	record.MaxUnused = record.Unused.Max
+	record.MaxUsed = record.Used.Max
+	record.MaxMemory = record.Memory.Max
+	record.MaxArchive = record.Archive.Max
+	if setting.OutputHistogram {
+		record.UnusedHistogram = helpers.Histogram(metrics.unused)
+		record.UsedHistogram = helpers.Histogram(metrics.used)
+		record.MemoryHistogram = helpers.Histogram(metrics.memory)
+		record.ArchiveHistogram = helpers.Histogram(metrics.archive)
+	}
+
+	fmt.Println(block.Height, " ", blockHashStr)
+	fmt.Printf("unused %d, used %d, memory %d (mean %.1f, p99 %.1f), storage %d\n",
+		record.MaxUnused, record.MaxUsed, record.MaxMemory, record.Memory.Mean, record.Memory.P99, record.MaxArchive)
+	fmt.Printf("mempool %d, included %d, evicted %d, rejected %d\n", record.MempoolSize.Max, stats.Mempool.Included,
+		stats.Mempool.EvictedSpent+stats.Mempool.EvictedStale+stats.Mempool.EvictedFull, stats.Mempool.Rejected)
+	fmt.Printf("proof updated txs %d, updated hashes %d, log lookups %d\n",
+		stats.Build.UpdatedTransactions, stats.Build.UpdatedHashes, stats.Build.LogLookups)
+	fmt.Printf("txs %d, inputs %d, outputs %d, block size %d, total fee %d\n",
+		stats.Build.Transactions, stats.Build.Inputs, stats.Build.Outputs, stats.Build.BlockSize, stats.Build.TotalFee)
+	fmt.Printf("proof bytes %d (individual %d), proof hashes %d\n", stats.Build.ProofBytes, stats.Build.IndividualProofBytes, stats.Build.ProofHashes)
+	fmt.Printf("senders %d, issued %v, failed %d, unmatched %d, unaffordable %d, memory freed %d (naive %d)\n",
+		stats.Workload.Senders, stats.Workload.Issued, stats.Workload.Failed, stats.Workload.Unmatched, stats.Workload.Unaffordable,
+		stats.Workload.MemoryFreed, stats.Workload.MemoryFreedNaive)
+	if stats.Churn.Joined > 0 || stats.Churn.Left > 0 {
+		fmt.Printf("clients %d, joined %d, left %d, abandoned %d txos (balance %d)\n",
+			len(clients), stats.Churn.Joined, stats.Churn.Left, stats.Churn.AbandonedTXOs, stats.Churn.AbandonedBalance)
+	}
+	for _, violation := range stats.Violations {
+		if violation.Address != nil {
+			fmt.Printf("violation %s: client %d, txo %s, %s\n", violation.Invariant, *violation.Address, violation.Index, violation.Detail)
+		} else if violation.Index != "" {
+			fmt.Printf("violation %s: txo %s, %s\n", violation.Invariant, violation.Index, violation.Detail)
+		} else {
+			fmt.Printf("violation %s: %s\n", violation.Invariant, violation.Detail)
+		}
+	}
+	fmt.Printf("finalized height %d, pruned fork bytes %d\n", record.FinalizedHeight, record.PrunedForkData.Bytes)
+	fmt.Printf("proof branches %d (mean %.1f), shared %d (mean %.1f)\n", record.ProofBranches.Max, record.ProofBranches.Mean,
+		record.SharedProofBranches.Max, record.SharedProofBranches.Mean)
+	fmt.Printf("blocks %d, txos %d, history bytes %d\n",
+		record.Blocks.Max, record.TXOs.Max, record.HistoryBytes.Serialized.Max)
+	fmt.Printf("memory bytes %d, full node branches bytes %d (%d updates, %d pruned)\n",
+		record.MemoryBytes.Serialized.Max, record.FullNodeBytes.Serialized.Branches, record.BranchLogEntries, record.PrunedBranchLogEntries)
+
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		panic(err)
+	}
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+	file.Write(recordBytes)
+	if block.Height == setting.EndBlockHeight {
+		file.WriteString("]}")
+	} else {
+		file.WriteString(",")
+	}
+}
@@ -11,8 +11,119 @@ const (
 	ArchiveHeight = 50
 
 	TotalBalance = 100000000
+
+	// GenesisFile is path of JSON file of genesis allocations, whose balances must sum up to TotalBalance.
+	// e.g. {"allocations": [{"address": 0, "balances": [60000000, 30000000]}, {"address": 1, "count": 1000, "balance": 10000}]}
+	// Empty path gives each client a TXO of TotalBalance/NumberOfClient.
+	GenesisFile = ""
+
+	// Fee of a transaction is FeePerByte for each byte of encoded transaction,
+	// FeePerTXO for each input TXO and FeePerOutput for each output TXO.
+	FeePerByte   = 1
 	FeePerTXO    = 10
+	FeePerOutput = 0
 
-	// InputsPerBlock is rough number of input TXOs to include in the block.
+	// InputsPerBlock is mean number of clients sending transactions for each block.
 	InputsPerBlock = 50
+
+	// JoinRate and LeaveRate are mean numbers of clients joining and leaving for each block.
+	// Joining clients bootstrap from full node, and leaving clients abandon their TXOs. Clients of nodes never leave.
+	JoinRate  = 0.0
+	LeaveRate = 0.0
+
+	// Workload is the model of how often clients send and receive. One of "uniform", "zipf" and "merchant".
+	// "zipf" makes activity of clients follow Zipf's law with ZipfExponent.
+	// "merchant" makes MerchantFraction of clients merchants receiving MerchantShare of payments in total.
+	Workload         = "uniform"
+	ZipfExponent     = 1.0
+	MerchantFraction = 0.05
+	MerchantShare    = 0.5
+
+	// AmountDistribution is the distribution of payment amounts. One of "uniform", "exponential" and "pareto".
+	// Amounts are at most half of sender's balance, and AmountMeanFraction of it on average except for "uniform".
+	AmountDistribution = "uniform"
+	AmountMeanFraction = 0.25
+	AmountParetoShape  = 1.5
+
+	// ArrivalPattern is how the number of senders varies by block. One of "constant", "periodic" and "poisson".
+	// "periodic" varies it along sine wave of ArrivalPeriod blocks by ArrivalAmplitude of InputsPerBlock.
+	ArrivalPattern   = "constant"
+	ArrivalPeriod    = 24
+	ArrivalAmplitude = 0.5
+
+	// TraceFile is path of CSV or NDJSON file of (time, sender, receiver, amount) records clients replay
+	// instead of generating transactions. Records in each TraceBlockInterval of time are replayed for a block,
+	// and amounts are multiplied by TraceAmountScale. Empty path disables replay.
+	TraceFile          = ""
+	TraceBlockInterval = 600.0
+	TraceAmountScale   = 1.0
+
+	// Weights of transaction shapes clients issue. A shape with weight 0 is never issued.
+	// Merge spends all TXOs of two clients and splits the total in half.
+	// Payment pays a random amount up to half of sender's balance to another client.
+	// Batch pays BatchRecipients clients from a client, like batch payouts and exchange withdrawals.
+	// MultiSender pays a client from MultiSenders clients.
+	// Consolidation merges all TXOs of a client into a TXO.
+	MergeWeight         = 1
+	PaymentWeight       = 0
+	BatchWeight         = 0
+	MultiSenderWeight   = 0
+	ConsolidationWeight = 0
+	BatchRecipients     = 5
+	MultiSenders        = 3
+
+	// CoinSelection is the strategy to select TXOs to spend except for merge and consolidation.
+	// One of "all", "largest-first", "smallest-first", "random", "branch-and-bound" and "locality".
+	// "locality" prefers TXOs sharing proof branches to free client memory.
+	CoinSelection = "largest-first"
+
+	// IndexAllocation is the policy to assign leaf indexes to new TXOs in a block.
+	// "sequential" assigns in order of transactions and the reward TXO last.
+	// "owner" groups TXOs by owner in order of first appearance.
+	// "address" sorts TXOs by owner address.
+	// "reward-last" sorts TXOs by owner address and assigns the reward TXO last.
+	IndexAllocation = "sequential"
+
+	// MaxBlockSize is the maximum bytes of block header and transactions in a block.
+	MaxBlockSize = 500000
+
+	// ProofValidityBlocks is the number of latest blocks nodes accept proofs against.
+	// Nodes update proofs against older blocks than parent block with branch logs.
+	// 1 accepts only proofs against parent block.
+	ProofValidityBlocks = 3
+
+	// Multiproof makes transactions prove their inputs together by a multiproof, which includes
+	// each sibling hash once and omits siblings on paths of other inputs, instead of a proof per input.
+	Multiproof = false
+
+	// MaxMempoolSize is the maximum number of pending transactions each node holds.
+	MaxMempoolSize = 1000
+
+	// OutputHistogram enables per block histograms of client metrics in output.
+	OutputHistogram = false
+
+	// ReferenceCheck enables differential check of each block against a reference Merkle tree
+	// which recomputes the whole tree. Mismatches are reported as violations.
+	ReferenceCheck = false
+
+	// BranchPruneDepth is the depth from head block below which full nodes discard
+	// superseded branch updates and updates of abandoned forks. 0 disables pruning.
+	BranchPruneDepth = 0
+
+	// FinalityDepth is the depth from head block below which clients drop used TXOs,
+	// block hashes and own TXO history from memory. 0 disables pruning.
+	FinalityDepth = 0
+
+	// KeepHistory makes clients move data pruned after finality to history store.
+	KeepHistory = false
+
+	// CheckpointMode is how nodes agree on checkpoints.
+	// "none" disables checkpoints.
+	// "confirmation" makes the block CheckpointDepth blocks before head block a checkpoint.
+	// "quorum" makes a checkpoint every CheckpointInterval blocks when CheckpointQuorum nodes sign the same block
+	// CheckpointDepth blocks before head block.
+	CheckpointMode     = "none"
+	CheckpointDepth    = 6
+	CheckpointInterval = 10
+	CheckpointQuorum   = 7
 )
@@ -1,5 +1,11 @@
 package types
 
+import (
+	"errors"
+	"math/big"
+	"strings"
+)
+
 // Uint256 is 256 bit uint.
 type Uint256 [32]byte
 
@@ -49,15 +55,187 @@ func (u Uint256) Divide2() Uint256 {
 	return newVal
 }
 
-// Larger returns u >= b.
+// Larger returns u > b.
 func (u Uint256) Larger(b Uint256) bool {
-	if u == b {
-		return false
+	for i := 31; i >= 0; i-- {
+		if u[i] != b[i] {
+			return u[i] > b[i]
+		}
+	}
+	return false
+}
+
+// Cmp returns -1 if u < b, 0 if u == b and +1 if u > b.
+func (u Uint256) Cmp(b Uint256) int {
+	for i := 31; i >= 0; i-- {
+		if u[i] < b[i] {
+			return -1
+		}
+		if u[i] > b[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// IsZero returns u == 0.
+func (u Uint256) IsZero() bool {
+	return u == Uint256{}
+}
+
+// Sub returns u - b modulo 2^256.
+func (u Uint256) Sub(b Uint256) Uint256 {
+	newVal := [32]byte{}
+	borrow := int16(0)
+	for i := 0; i < 32; i++ {
+		diff := int16(u[i]) - int16(b[i]) - borrow
+		borrow = 0
+		if diff < 0 {
+			diff += 256
+			borrow = 1
+		}
+		newVal[i] = uint8(diff)
+	}
+	return newVal
+}
+
+// Mul returns u * b modulo 2^256.
+func (u Uint256) Mul(b Uint256) Uint256 {
+	var products [32]uint64
+	for i := 0; i < 32; i++ {
+		for j := 0; i+j < 32; j++ {
+			products[i+j] += uint64(u[i]) * uint64(b[j])
+		}
 	}
+	newVal := [32]byte{}
+	carryUp := uint64(0)
 	for i := 0; i < 32; i++ {
-		if u[31-i] < b[31-i] {
-			return false
+		sum := products[i] + carryUp
+		newVal[i] = uint8(sum)
+		carryUp = sum >> 8
+	}
+	return newVal
+}
+
+// Lsh returns u << n.
+func (u Uint256) Lsh(n uint) Uint256 {
+	newVal := [32]byte{}
+	if n >= 256 {
+		return newVal
+	}
+	bytes, bits := int(n/8), n%8
+	for i := 31; i >= bytes; i-- {
+		newVal[i] = u[i-bytes] << bits
+		if bits != 0 && i-bytes > 0 {
+			newVal[i] |= u[i-bytes-1] >> (8 - bits)
+		}
+	}
+	return newVal
+}
+
+// Rsh returns u >> n.
+func (u Uint256) Rsh(n uint) Uint256 {
+	newVal := [32]byte{}
+	if n >= 256 {
+		return newVal
+	}
+	bytes, bits := int(n/8), n%8
+	for i := 0; i+bytes < 32; i++ {
+		newVal[i] = u[i+bytes] >> bits
+		if bits != 0 && i+bytes < 31 {
+			newVal[i] |= u[i+bytes+1] << (8 - bits)
 		}
 	}
-	return true
+	return newVal
+}
+
+// Bit returns the value of the i'th bit of u, which is 0 or 1. Bits beyond 255 are 0.
+func (u Uint256) Bit(i uint) uint {
+	if i >= 256 {
+		return 0
+	}
+	return uint(u[i/8]>>(i%8)) & 1
+}
+
+// SetBit returns u with the i'th bit set to b, which is 0 or 1.
+func (u Uint256) SetBit(i uint, b uint) Uint256 {
+	if i >= 256 {
+		panic("Uint256.SetBit: bit index out of range")
+	}
+	if b == 0 {
+		u[i/8] &^= 1 << (i % 8)
+	} else {
+		u[i/8] |= 1 << (i % 8)
+	}
+	return u
+}
+
+// FromUint64 returns v as Uint256.
+func FromUint64(v uint64) Uint256 {
+	var u Uint256
+	for i := 0; i < 8; i++ {
+		u[i] = uint8(v >> (8 * i))
+	}
+	return u
+}
+
+// IsUint64 reports whether u can be represented as uint64.
+func (u Uint256) IsUint64() bool {
+	return u.Rsh(64).IsZero()
+}
+
+// Uint64 returns the low 64 bits of u.
+func (u Uint256) Uint64() uint64 {
+	v := uint64(0)
+	for i := 0; i < 8; i++ {
+		v |= uint64(u[i]) << (8 * i)
+	}
+	return v
+}
+
+// FromBig returns b as Uint256. It fails if b is negative or doesn't fit in 256 bits.
+func FromBig(b *big.Int) (Uint256, error) {
+	var u Uint256
+	if b.Sign() < 0 || b.BitLen() > 256 {
+		return u, errors.New("FromBig: out of range " + b.String())
+	}
+	bigEndian := b.Bytes()
+	for i, v := range bigEndian {
+		u[len(bigEndian)-1-i] = v
+	}
+	return u, nil
+}
+
+// Big returns u as big.Int.
+func (u Uint256) Big() *big.Int {
+	var bigEndian [32]byte
+	for i, v := range u {
+		bigEndian[31-i] = v
+	}
+	return new(big.Int).SetBytes(bigEndian[:])
+}
+
+// String returns u in hexadecimal without leading zeros.
+func (u Uint256) String() string {
+	return u.Big().Text(16)
+}
+
+// MarshalText implements encoding.TextMarshaler, which JSON uses for values and map keys.
+func (u Uint256) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It accepts hexadecimal with optional 0x prefix.
+func (u *Uint256) UnmarshalText(text []byte) error {
+	digits := strings.TrimPrefix(strings.ToLower(string(text)), "0x")
+	b, ok := new(big.Int).SetString(digits, 16)
+	if !ok || digits == "" || strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		return errors.New("Uint256.UnmarshalText: invalid hexadecimal " + string(text))
+	}
+	v, err := FromBig(b)
+	if err != nil {
+		return err
+	}
+	*u = v
+	return nil
 }
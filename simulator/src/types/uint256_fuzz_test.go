@@ -0,0 +1,179 @@
+//go:build go1.18
+// +build go1.18
+
+package types
+
+import (
+	"math/big"
+	"testing"
+)
+
+var modulus = new(big.Int).Lsh(big.NewInt(1), 256)
+
+// fromBytes builds Uint256 from fuzzed bytes, ignoring bytes beyond 32.
+func fromBytes(b []byte) Uint256 {
+	var u Uint256
+	copy(u[:], b)
+	return u
+}
+
+// toBig converts little endian Uint256 to big.Int.
+func toBig(u Uint256) *big.Int {
+	var be [32]byte
+	for i := 0; i < 32; i++ {
+		be[31-i] = u[i]
+	}
+	return new(big.Int).SetBytes(be[:])
+}
+
+func FuzzUint256_Add(f *testing.F) {
+	f.Add([]byte{1}, []byte{255})
+	f.Add([]byte{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255,
+		255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255}, []byte{1})
+	f.Fuzz(func(t *testing.T, a []byte, b []byte) {
+		u, v := fromBytes(a), fromBytes(b)
+		want := new(big.Int).Add(toBig(u), toBig(v))
+		want.Mod(want, modulus)
+		if got := toBig(u.Add(v)); got.Cmp(want) != 0 {
+			t.Errorf("%v.Add(%v) = %x, want %x", u, v, got, want)
+		}
+	})
+}
+
+func FuzzUint256_AddUint8(f *testing.F) {
+	f.Add([]byte{255}, 1)
+	f.Add([]byte{}, 300)
+	f.Fuzz(func(t *testing.T, a []byte, b int) {
+		u := fromBytes(a)
+		// AddUint8 adds the lowest byte of b.
+		want := new(big.Int).Add(toBig(u), big.NewInt(int64(uint8(b))))
+		want.Mod(want, modulus)
+		if got := toBig(u.AddUint8(b)); got.Cmp(want) != 0 {
+			t.Errorf("%v.AddUint8(%d) = %x, want %x", u, b, got, want)
+		}
+	})
+}
+
+func FuzzUint256_Divide2(f *testing.F) {
+	f.Add([]byte{1})
+	f.Add([]byte{0, 1})
+	f.Fuzz(func(t *testing.T, a []byte) {
+		u := fromBytes(a)
+		want := new(big.Int).Rsh(toBig(u), 1)
+		if got := toBig(u.Divide2()); got.Cmp(want) != 0 {
+			t.Errorf("%v.Divide2() = %x, want %x", u, got, want)
+		}
+	})
+}
+
+func FuzzUint256_Larger(f *testing.F) {
+	f.Add([]byte{1}, []byte{})
+	f.Add([]byte{1}, []byte{2})
+	f.Fuzz(func(t *testing.T, a []byte, b []byte) {
+		u, v := fromBytes(a), fromBytes(b)
+		want := toBig(u).Cmp(toBig(v)) > 0
+		if got := u.Larger(v); got != want {
+			t.Errorf("%v.Larger(%v) = %v, want %v", u, v, got, want)
+		}
+	})
+}
+
+func FuzzUint256_Cmp(f *testing.F) {
+	f.Add([]byte{0, 1}, []byte{1})
+	f.Add([]byte{1}, []byte{1})
+	f.Fuzz(func(t *testing.T, a []byte, b []byte) {
+		u, v := fromBytes(a), fromBytes(b)
+		if got, want := u.Cmp(v), toBig(u).Cmp(toBig(v)); got != want {
+			t.Errorf("%v.Cmp(%v) = %d, want %d", u, v, got, want)
+		}
+	})
+}
+
+func FuzzUint256_Sub(f *testing.F) {
+	f.Add([]byte{0, 1}, []byte{1})
+	f.Add([]byte{}, []byte{1})
+	f.Fuzz(func(t *testing.T, a []byte, b []byte) {
+		u, v := fromBytes(a), fromBytes(b)
+		want := new(big.Int).Sub(toBig(u), toBig(v))
+		want.Mod(want, modulus)
+		if got := toBig(u.Sub(v)); got.Cmp(want) != 0 {
+			t.Errorf("%v.Sub(%v) = %x, want %x", u, v, got, want)
+		}
+	})
+}
+
+func FuzzUint256_Mul(f *testing.F) {
+	f.Add([]byte{255}, []byte{255})
+	f.Add([]byte{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255,
+		255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255}, []byte{255, 255})
+	f.Fuzz(func(t *testing.T, a []byte, b []byte) {
+		u, v := fromBytes(a), fromBytes(b)
+		want := new(big.Int).Mul(toBig(u), toBig(v))
+		want.Mod(want, modulus)
+		if got := toBig(u.Mul(v)); got.Cmp(want) != 0 {
+			t.Errorf("%v.Mul(%v) = %x, want %x", u, v, got, want)
+		}
+	})
+}
+
+func FuzzUint256_Shift(f *testing.F) {
+	f.Add([]byte{1, 2, 3}, uint(9))
+	f.Add([]byte{255}, uint(255))
+	f.Fuzz(func(t *testing.T, a []byte, n uint) {
+		n %= 300
+		u := fromBytes(a)
+		want := new(big.Int).Lsh(toBig(u), n)
+		want.Mod(want, modulus)
+		if got := toBig(u.Lsh(n)); got.Cmp(want) != 0 {
+			t.Errorf("%v.Lsh(%d) = %x, want %x", u, n, got, want)
+		}
+		want = new(big.Int).Rsh(toBig(u), n)
+		if got := toBig(u.Rsh(n)); got.Cmp(want) != 0 {
+			t.Errorf("%v.Rsh(%d) = %x, want %x", u, n, got, want)
+		}
+	})
+}
+
+func FuzzUint256_Bit(f *testing.F) {
+	f.Add([]byte{1}, uint(0), uint(0))
+	f.Add([]byte{0, 2}, uint(9), uint(1))
+	f.Fuzz(func(t *testing.T, a []byte, i uint, b uint) {
+		i, b = i%256, b%2
+		u := fromBytes(a)
+		if got, want := u.Bit(i), toBig(u).Bit(int(i)); got != want {
+			t.Errorf("%v.Bit(%d) = %d, want %d", u, i, got, want)
+		}
+		want := new(big.Int).SetBit(toBig(u), int(i), b)
+		if got := toBig(u.SetBit(i, b)); got.Cmp(want) != 0 {
+			t.Errorf("%v.SetBit(%d, %d) = %x, want %x", u, i, b, got, want)
+		}
+	})
+}
+
+func FuzzUint256_Conversion(f *testing.F) {
+	f.Add([]byte{1, 2, 3})
+	f.Add([]byte{0, 0, 0, 0, 0, 0, 0, 0, 1})
+	f.Fuzz(func(t *testing.T, a []byte) {
+		u := fromBytes(a)
+		if got := u.Big(); got.Cmp(toBig(u)) != 0 {
+			t.Errorf("%v.Big() = %x, want %x", u, got, toBig(u))
+		}
+		if got, err := FromBig(toBig(u)); err != nil || got != u {
+			t.Errorf("FromBig(%x) = %v, %v", toBig(u), got, err)
+		}
+		if got, want := u.IsUint64(), toBig(u).IsUint64(); got != want {
+			t.Errorf("%v.IsUint64() = %v, want %v", u, got, want)
+		}
+		if u.IsUint64() && FromUint64(u.Uint64()) != u {
+			t.Errorf("FromUint64(%v.Uint64()) = %v", u, FromUint64(u.Uint64()))
+		}
+		text, err := u.MarshalText()
+		if err != nil || string(text) != toBig(u).Text(16) {
+			t.Errorf("%v.MarshalText() = %s, %v", u, text, err)
+		}
+		var decoded Uint256
+		if err := decoded.UnmarshalText(text); err != nil || decoded != u {
+			t.Errorf("UnmarshalText(%s) = %v, %v", text, decoded, err)
+		}
+	})
+}
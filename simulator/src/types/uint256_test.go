@@ -1,7 +1,10 @@
 package types
 
 import (
+	"encoding/json"
+	"math/big"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -178,6 +181,18 @@ func TestUint256_Larger(t *testing.T) {
 			args: args{Uint256{2}},
 			want: false,
 		},
+		{
+			name: "256 larger than 1",
+			u:    Uint256{0, 1},
+			args: args{Uint256{1}},
+			want: true,
+		},
+		{
+			name: "1 not larger than 256",
+			u:    Uint256{1},
+			args: args{Uint256{0, 1}},
+			want: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -187,3 +202,197 @@ func TestUint256_Larger(t *testing.T) {
 		})
 	}
 }
+
+func TestUint256_Cmp(t *testing.T) {
+	tests := []struct {
+		name string
+		u    Uint256
+		b    Uint256
+		want int
+	}{
+		{
+			name: "0 equals 0",
+			u:    Uint256{},
+			b:    Uint256{},
+			want: 0,
+		},
+		{
+			name: "1 less than 256",
+			u:    Uint256{1},
+			b:    Uint256{0, 1},
+			want: -1,
+		},
+		{
+			name: "256 greater than 255",
+			u:    Uint256{0, 1},
+			b:    Uint256{255},
+			want: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.u.Cmp(tt.b); got != tt.want {
+				t.Errorf("Uint256.Cmp() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUint256_Sub(t *testing.T) {
+	tests := []struct {
+		name string
+		u    Uint256
+		b    Uint256
+		want Uint256
+	}{
+		{
+			name: "subtract 1 from 256",
+			u:    Uint256{0, 1},
+			b:    Uint256{1},
+			want: Uint256{255},
+		},
+		{
+			name: "subtract 1 from 0",
+			u:    Uint256{},
+			b:    Uint256{1},
+			want: Uint256{}.Max(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.u.Sub(tt.b); got != tt.want {
+				t.Errorf("Uint256.Sub() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUint256_Mul(t *testing.T) {
+	tests := []struct {
+		name string
+		u    Uint256
+		b    Uint256
+		want Uint256
+	}{
+		{
+			name: "multiply 255 by 255",
+			u:    Uint256{255},
+			b:    Uint256{255},
+			want: Uint256{1, 254},
+		},
+		{
+			name: "multiply max by 2",
+			u:    Uint256{}.Max(),
+			b:    Uint256{2},
+			want: Uint256{}.Max().Sub(Uint256{1}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.u.Mul(tt.b); got != tt.want {
+				t.Errorf("Uint256.Mul() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUint256_Shift(t *testing.T) {
+	tests := []struct {
+		name string
+		u    Uint256
+		n    uint
+		lsh  Uint256
+		rsh  Uint256
+	}{
+		{
+			name: "shift by 0",
+			u:    Uint256{1, 2},
+			n:    0,
+			lsh:  Uint256{1, 2},
+			rsh:  Uint256{1, 2},
+		},
+		{
+			name: "shift by 9",
+			u:    Uint256{0, 3},
+			n:    9,
+			lsh:  Uint256{0, 0, 6},
+			rsh:  Uint256{1},
+		},
+		{
+			name: "shift by 256",
+			u:    Uint256{}.Max(),
+			n:    256,
+			lsh:  Uint256{},
+			rsh:  Uint256{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.u.Lsh(tt.n); got != tt.lsh {
+				t.Errorf("Uint256.Lsh() = %v, want %v", got, tt.lsh)
+			}
+			if got := tt.u.Rsh(tt.n); got != tt.rsh {
+				t.Errorf("Uint256.Rsh() = %v, want %v", got, tt.rsh)
+			}
+		})
+	}
+}
+
+func TestUint256_Bit(t *testing.T) {
+	u := Uint256{}.SetBit(9, 1)
+	if u != (Uint256{0, 2}) {
+		t.Errorf("Uint256.SetBit() = %v, want %v", u, Uint256{0, 2})
+	}
+	if u.Bit(9) != 1 || u.Bit(8) != 0 || u.Bit(256) != 0 {
+		t.Errorf("Uint256.Bit() of %v is wrong", u)
+	}
+	if u = u.SetBit(9, 0); !u.IsZero() {
+		t.Errorf("Uint256.SetBit() = %v, want 0", u)
+	}
+}
+
+func TestUint256_Conversion(t *testing.T) {
+	u := FromUint64(0x0102)
+	if u != (Uint256{2, 1}) || !u.IsUint64() || u.Uint64() != 0x0102 {
+		t.Errorf("FromUint64() = %v", u)
+	}
+	if u.Lsh(64).IsUint64() {
+		t.Errorf("%v doesn't fit in uint64", u.Lsh(64))
+	}
+	if got := u.Big(); got.Cmp(big.NewInt(0x0102)) != 0 {
+		t.Errorf("Uint256.Big() = %v, want 258", got)
+	}
+	if got, err := FromBig(big.NewInt(0x0102)); err != nil || got != u {
+		t.Errorf("FromBig() = %v, %v, want %v", got, err, u)
+	}
+	if _, err := FromBig(big.NewInt(-1)); err == nil {
+		t.Error("FromBig() of negative value succeeded")
+	}
+	if _, err := FromBig(new(big.Int).Lsh(big.NewInt(1), 256)); err == nil {
+		t.Error("FromBig() of 2^256 succeeded")
+	}
+}
+
+func TestUint256_JSON(t *testing.T) {
+	u := Uint256{0x0f, 0x01}
+	if got := u.String(); got != "10f" {
+		t.Errorf("Uint256.String() = %v, want 10f", got)
+	}
+	data, err := json.Marshal(map[Uint256]Uint256{u: {}})
+	if err != nil || string(data) != `{"10f":"0"}` {
+		t.Errorf("json.Marshal() = %s, %v", data, err)
+	}
+	var decoded map[Uint256]Uint256
+	if err := json.Unmarshal(data, &decoded); err != nil || !reflect.DeepEqual(decoded, map[Uint256]Uint256{u: {}}) {
+		t.Errorf("json.Unmarshal() = %v, %v", decoded, err)
+	}
+	for _, text := range []string{"", "0x", "-1", "xyz", "1" + strings.Repeat("0", 64)} {
+		if err := new(Uint256).UnmarshalText([]byte(text)); err == nil {
+			t.Errorf("Uint256.UnmarshalText(%q) succeeded", text)
+		}
+	}
+	var v Uint256
+	if err := v.UnmarshalText([]byte("0xFF")); err != nil || v != (Uint256{255}) {
+		t.Errorf("Uint256.UnmarshalText(0xFF) = %v, %v", v, err)
+	}
+}
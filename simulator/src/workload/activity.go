@@ -0,0 +1,106 @@
+package workload
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"trail_simulator/simulator/src/setting"
+)
+
+// Activity decides how often each client sends and receives transactions.
+type Activity interface {
+	// Weights returns relative frequencies of sending and receiving of clients.
+	Weights(numberOfClients int) (send []float64, receive []float64)
+}
+
+type uniformActivity struct{}
+type zipfActivity struct{ exponent float64 }
+type merchantActivity struct {
+	fraction float64
+	share    float64
+}
+
+// NewActivity returns activity of the model.
+// Model is one of "uniform", "zipf" and "merchant".
+func NewActivity(model string) (Activity, error) {
+	switch model {
+	case "uniform":
+		return uniformActivity{}, nil
+	case "zipf":
+		if setting.ZipfExponent <= 0 {
+			return nil, errors.New("NewActivity: ZipfExponent must be positive")
+		}
+		return zipfActivity{setting.ZipfExponent}, nil
+	case "merchant":
+		if setting.MerchantFraction <= 0 || setting.MerchantFraction >= 1 {
+			return nil, errors.New("NewActivity: MerchantFraction must be between 0 and 1")
+		}
+		if setting.MerchantShare < 0 || setting.MerchantShare > 1 {
+			return nil, errors.New("NewActivity: MerchantShare must be between 0 and 1")
+		}
+		return merchantActivity{setting.MerchantFraction, setting.MerchantShare}, nil
+	}
+	return nil, errors.New("NewActivity: unknown model " + model)
+}
+
+// Weights returns the same weight for all clients.
+func (a uniformActivity) Weights(numberOfClients int) ([]float64, []float64) {
+	weights := make([]float64, numberOfClients)
+	for i := range weights {
+		weights[i] = 1
+	}
+	return weights, weights
+}
+
+// Weights returns weights following Zipf's law over clients ranked in random order.
+// The most active senders are also the most active recipients.
+func (a zipfActivity) Weights(numberOfClients int) ([]float64, []float64) {
+	weights := make([]float64, numberOfClients)
+	for rank, i := range rand.Perm(numberOfClients) {
+		weights[i] = 1 / math.Pow(float64(rank+1), a.exponent)
+	}
+	return weights, weights
+}
+
+// Weights returns uniform send weights and receive weights where randomly chosen merchants
+// receive share of payments in total.
+func (a merchantActivity) Weights(numberOfClients int) ([]float64, []float64) {
+	send, _ := uniformActivity{}.Weights(numberOfClients)
+	receive := make([]float64, numberOfClients)
+	merchants := int(math.Ceil(a.fraction * float64(numberOfClients)))
+	for rank, i := range rand.Perm(numberOfClients) {
+		if rank < merchants {
+			receive[i] = a.share / float64(merchants)
+		} else {
+			receive[i] = (1 - a.share) / float64(numberOfClients-merchants)
+		}
+	}
+	return send, receive
+}
+
+// pickWeighted returns random index of weights in proportion to weight except excluded indexes.
+// It returns -1 if no index has positive weight.
+func pickWeighted(weights []float64, exclude map[int]bool) int {
+	total := 0.0
+	for i, weight := range weights {
+		if !exclude[i] {
+			total += weight
+		}
+	}
+	if total <= 0 {
+		return -1
+	}
+	r := rand.Float64() * total
+	last := -1
+	for i, weight := range weights {
+		if exclude[i] || weight <= 0 {
+			continue
+		}
+		if r < weight {
+			return i
+		}
+		r -= weight
+		last = i
+	}
+	return last
+}
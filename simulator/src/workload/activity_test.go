@@ -0,0 +1,77 @@
+package workload
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestActivity_Weights(t *testing.T) {
+	rand.Seed(1)
+	const clients = 100
+
+	t.Run("uniform", func(t *testing.T) {
+		send, receive := uniformActivity{}.Weights(clients)
+		for i := range send {
+			if send[i] != 1 || receive[i] != 1 {
+				t.Fatalf("weights of client %d = %v, %v, want 1, 1", i, send[i], receive[i])
+			}
+		}
+	})
+	t.Run("zipf", func(t *testing.T) {
+		send, receive := zipfActivity{2}.Weights(clients)
+		sorted := append([]float64{}, send...)
+		sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+		for rank, weight := range sorted {
+			if want := 1 / math.Pow(float64(rank+1), 2); weight != want {
+				t.Fatalf("weight of rank %d = %v, want %v", rank+1, weight, want)
+			}
+		}
+		for i := range send {
+			if send[i] != receive[i] {
+				t.Fatalf("weights of client %d differ: %v, %v", i, send[i], receive[i])
+			}
+		}
+	})
+	t.Run("merchant", func(t *testing.T) {
+		send, receive := merchantActivity{0.05, 0.6}.Weights(clients)
+		merchants, total := 0, 0.0
+		for i := range send {
+			if send[i] != 1 {
+				t.Fatalf("send weight of client %d = %v, want 1", i, send[i])
+			}
+			switch receive[i] {
+			case 0.6 / 5:
+				merchants++
+			case 0.4 / 95:
+			default:
+				t.Fatalf("receive weight of client %d = %v", i, receive[i])
+			}
+			total += receive[i]
+		}
+		if merchants != 5 || math.Abs(total-1) > 1e-9 {
+			t.Errorf("%d merchants and total receive weight %v, want 5 and 1", merchants, total)
+		}
+	})
+}
+
+func TestPickWeighted(t *testing.T) {
+	rand.Seed(1)
+	weights := []float64{1, 0, 3, 2}
+	counts := make([]int, len(weights))
+	for i := 0; i < 6000; i++ {
+		picked := pickWeighted(weights, map[int]bool{3: true})
+		if picked != 0 && picked != 2 {
+			t.Fatalf("pickWeighted() = %d, which has no weight or is excluded", picked)
+		}
+		counts[picked]++
+	}
+	// index 2 has 3 times the weight of index 0.
+	if counts[2] < 2*counts[0] || counts[2] > 4*counts[0] {
+		t.Errorf("pickWeighted() picked indexes %v times", counts)
+	}
+	if picked := pickWeighted(weights, map[int]bool{0: true, 2: true, 3: true}); picked != -1 {
+		t.Errorf("pickWeighted() without positive weight = %d, want -1", picked)
+	}
+}
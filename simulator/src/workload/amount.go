@@ -0,0 +1,78 @@
+package workload
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"trail_simulator/simulator/src/setting"
+)
+
+// AmountDistribution decides amounts of payments.
+type AmountDistribution interface {
+	// Amount returns random amount between 1 and max. It returns 0 if max is 0.
+	Amount(max uint64) uint64
+}
+
+type uniformAmount struct{}
+type exponentialAmount struct{ mean float64 }
+type paretoAmount struct {
+	mean  float64
+	shape float64
+}
+
+// NewAmountDistribution returns amount distribution of the name.
+// Name is one of "uniform", "exponential" and "pareto".
+func NewAmountDistribution(name string) (AmountDistribution, error) {
+	if setting.AmountMeanFraction <= 0 || setting.AmountMeanFraction > 1 {
+		return nil, errors.New("NewAmountDistribution: AmountMeanFraction must be between 0 and 1")
+	}
+	switch name {
+	case "uniform":
+		return uniformAmount{}, nil
+	case "exponential":
+		return exponentialAmount{setting.AmountMeanFraction}, nil
+	case "pareto":
+		if setting.AmountParetoShape <= 1 {
+			return nil, errors.New("NewAmountDistribution: AmountParetoShape must be greater than 1")
+		}
+		return paretoAmount{setting.AmountMeanFraction, setting.AmountParetoShape}, nil
+	}
+	return nil, errors.New("NewAmountDistribution: unknown distribution " + name)
+}
+
+// clamp returns amount rounded and limited between 1 and max.
+func clamp(amount float64, max uint64) uint64 {
+	if amount < 1 {
+		return 1
+	}
+	if amount >= float64(max) {
+		return max
+	}
+	return uint64(math.Round(amount))
+}
+
+// Amount returns amount uniformly distributed between 1 and max.
+func (d uniformAmount) Amount(max uint64) uint64 {
+	if max == 0 {
+		return 0
+	}
+	return uint64(rand.Int63n(int64(max))) + 1
+}
+
+// Amount returns amount exponentially distributed with mean of fraction of max.
+func (d exponentialAmount) Amount(max uint64) uint64 {
+	if max == 0 {
+		return 0
+	}
+	return clamp(rand.ExpFloat64()*d.mean*float64(max), max)
+}
+
+// Amount returns amount following Pareto distribution with mean of fraction of max,
+// so that most payments are small and a few are close to max.
+func (d paretoAmount) Amount(max uint64) uint64 {
+	if max == 0 {
+		return 0
+	}
+	minimum := d.mean * float64(max) * (d.shape - 1) / d.shape
+	return clamp(minimum/math.Pow(1-rand.Float64(), 1/d.shape), max)
+}
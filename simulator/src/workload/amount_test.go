@@ -0,0 +1,45 @@
+package workload
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestAmountDistribution_Amount(t *testing.T) {
+	rand.Seed(1)
+	const max = 1000
+	tests := []struct {
+		name         string
+		distribution AmountDistribution
+		min          uint64  // lower bound of amounts.
+		mean         float64 // expected mean, limited by max.
+	}{
+		{"uniform", uniformAmount{}, 1, (1 + max) / 2.0},
+		// mean of exponential distribution limited by max is mean * (1 - e^(-max/mean)).
+		{"exponential", exponentialAmount{0.25}, 1, 250 * (1 - 0.0183)},
+		// Pareto distribution starts at mean * (shape-1) / shape.
+		{"pareto", paretoAmount{0.25, 1.5}, 83, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.distribution.Amount(0); got != 0 {
+				t.Errorf("Amount(0) = %d, want 0", got)
+			}
+			if got := tt.distribution.Amount(1); got != 1 {
+				t.Errorf("Amount(1) = %d, want 1", got)
+			}
+			sum := 0.0
+			const samples = 20000
+			for i := 0; i < samples; i++ {
+				amount := tt.distribution.Amount(max)
+				if amount < tt.min || amount > max {
+					t.Fatalf("Amount(%d) = %d, want between %d and %d", max, amount, tt.min, max)
+				}
+				sum += float64(amount)
+			}
+			if mean := sum / samples; tt.mean > 0 && (mean < 0.95*tt.mean || mean > 1.05*tt.mean) {
+				t.Errorf("mean of Amount(%d) = %v, want about %v", max, mean, tt.mean)
+			}
+		})
+	}
+}
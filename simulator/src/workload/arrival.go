@@ -0,0 +1,66 @@
+package workload
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"trail_simulator/simulator/src/setting"
+)
+
+// ArrivalPattern decides how many clients send transactions for each block.
+type ArrivalPattern interface {
+	// Senders returns the number of senders for the block at the height when mean senders send on average.
+	Senders(height uint64, mean int) int
+}
+
+type constantArrival struct{}
+type periodicArrival struct {
+	period    uint64
+	amplitude float64
+}
+type poissonArrival struct{}
+
+// NewArrivalPattern returns arrival pattern of the name.
+// Name is one of "constant", "periodic" and "poisson".
+func NewArrivalPattern(name string) (ArrivalPattern, error) {
+	switch name {
+	case "constant":
+		return constantArrival{}, nil
+	case "periodic":
+		if setting.ArrivalPeriod == 0 {
+			return nil, errors.New("NewArrivalPattern: ArrivalPeriod must be positive")
+		}
+		if setting.ArrivalAmplitude < 0 || setting.ArrivalAmplitude > 1 {
+			return nil, errors.New("NewArrivalPattern: ArrivalAmplitude must be between 0 and 1")
+		}
+		return periodicArrival{setting.ArrivalPeriod, setting.ArrivalAmplitude}, nil
+	case "poisson":
+		return poissonArrival{}, nil
+	}
+	return nil, errors.New("NewArrivalPattern: unknown pattern " + name)
+}
+
+// Senders returns mean for all blocks.
+func (a constantArrival) Senders(height uint64, mean int) int {
+	return mean
+}
+
+// Senders returns senders varying along sine wave of the period, like daily peaks of activity.
+func (a periodicArrival) Senders(height uint64, mean int) int {
+	phase := 2 * math.Pi * float64(height%a.period) / float64(a.period)
+	return int(math.Round(float64(mean) * (1 + a.amplitude*math.Sin(phase))))
+}
+
+// Senders returns random senders following Poisson distribution.
+func (a poissonArrival) Senders(height uint64, mean int) int {
+	// Knuth's algorithm. Normal approximation is used for large mean to avoid underflow.
+	if mean > 500 {
+		return int(math.Max(0, math.Round(float64(mean)+rand.NormFloat64()*math.Sqrt(float64(mean)))))
+	}
+	limit := math.Exp(-float64(mean))
+	senders := 0
+	for p := rand.Float64(); p > limit; p *= rand.Float64() {
+		senders++
+	}
+	return senders
+}
@@ -0,0 +1,61 @@
+package workload
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestArrivalPattern_Senders(t *testing.T) {
+	rand.Seed(1)
+	t.Run("constant", func(t *testing.T) {
+		for height := uint64(0); height < 10; height++ {
+			if got := (constantArrival{}).Senders(height, 50); got != 50 {
+				t.Errorf("Senders(%d, 50) = %d, want 50", height, got)
+			}
+		}
+	})
+	t.Run("periodic", func(t *testing.T) {
+		a := periodicArrival{24, 0.5}
+		tests := []struct {
+			height uint64
+			want   int
+		}{
+			{0, 100},
+			{6, 150}, // peak at a quarter of period.
+			{12, 100},
+			{18, 50}, // trough at three quarters of period.
+			{30, 150},
+		}
+		for _, tt := range tests {
+			if got := a.Senders(tt.height, 100); got != tt.want {
+				t.Errorf("Senders(%d, 100) = %d, want %d", tt.height, got, tt.want)
+			}
+		}
+		for height := uint64(0); height < 48; height++ {
+			if got := a.Senders(height, 100); got < 50 || got > 150 {
+				t.Errorf("Senders(%d, 100) = %d, want between 50 and 150", height, got)
+			}
+		}
+	})
+	t.Run("poisson", func(t *testing.T) {
+		a := poissonArrival{}
+		if got := a.Senders(0, 0); got != 0 {
+			t.Errorf("Senders(0, 0) = %d, want 0", got)
+		}
+		// the normal approximation is used above mean of 500.
+		for _, mean := range []int{20, 1000} {
+			sum := 0
+			const samples = 5000
+			for i := 0; i < samples; i++ {
+				senders := a.Senders(uint64(i), mean)
+				if senders < 0 {
+					t.Fatalf("Senders(%d, %d) = %d, want non-negative", i, mean, senders)
+				}
+				sum += senders
+			}
+			if got := float64(sum) / samples; got < 0.98*float64(mean) || got > 1.02*float64(mean) {
+				t.Errorf("mean of Senders(_, %d) = %v, want about %d", mean, got, mean)
+			}
+		}
+	})
+}
@@ -0,0 +1,232 @@
+package workload
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"trail_simulator/simulator/src/models"
+)
+
+// Record is a payment recorded in a trace file.
+type Record struct {
+	Time     float64
+	Sender   string
+	Receiver string
+	Amount   float64
+}
+
+// Trace is recorded payments replayed by clients.
+// Records are grouped into blocks by time, and senders and receivers are mapped to
+// client addresses in order of first appearance.
+type Trace struct {
+	records     []Record // in order of time.
+	next        int      // index of the first record not replayed yet.
+	start       float64
+	interval    float64
+	amountScale float64
+	addresses   map[string]int
+}
+
+// LoadTrace reads records from CSV or NDJSON file decided by the extension of path.
+// CSV has columns time, sender, receiver and amount with optional header line.
+// NDJSON has objects with keys "time", "sender", "receiver" and "amount" for each line.
+// Times must be finite and amounts must be finite and not negative.
+// Records in each interval of time are replayed for a block, and their amounts are multiplied by amountScale.
+func LoadTrace(path string, interval float64, amountScale float64) (*Trace, error) {
+	if interval <= 0 || amountScale <= 0 {
+		return nil, errors.New("LoadTrace: interval and amount scale must be positive")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []Record
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		records, err = readCSV(file)
+	case ".ndjson", ".jsonl":
+		records, err = readNDJSON(file)
+	default:
+		return nil, errors.New("LoadTrace: unknown format of " + path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("LoadTrace: no records in " + path)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time < records[j].Time
+	})
+	return &Trace{
+		records:     records,
+		start:       records[0].Time,
+		interval:    interval,
+		amountScale: amountScale,
+		addresses:   map[string]int{}}, nil
+}
+
+func readCSV(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+	var records []Record
+	for line := 1; ; line++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		t, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			if line == 1 {
+				// header
+				continue
+			}
+			return nil, fmt.Errorf("readCSV: invalid time at line %d", line)
+		}
+		amount, err := strconv.ParseFloat(fields[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("readCSV: invalid amount at line %d", line)
+		}
+		record := Record{t, fields[1], fields[2], amount}
+		if err := record.validate(); err != nil {
+			return nil, fmt.Errorf("readCSV: %v at line %d", err, line)
+		}
+		records = append(records, record)
+	}
+}
+
+func readNDJSON(r io.Reader) ([]Record, error) {
+	scanner := bufio.NewScanner(r)
+	var records []Record
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var record struct {
+			Time     float64     `json:"time"`
+			Sender   interface{} `json:"sender"`
+			Receiver interface{} `json:"receiver"`
+			Amount   float64     `json:"amount"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("readNDJSON: %v at line %d", err, line)
+		}
+		if record.Sender == nil || record.Receiver == nil {
+			return nil, fmt.Errorf("readNDJSON: no sender or receiver at line %d", line)
+		}
+		r := Record{record.Time, fmt.Sprint(record.Sender), fmt.Sprint(record.Receiver), record.Amount}
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("readNDJSON: %v at line %d", err, line)
+		}
+		records = append(records, r)
+	}
+	return records, scanner.Err()
+}
+
+// validate returns error if time of the record isn't finite or its amount isn't finite and not negative.
+func (r Record) validate() error {
+	if math.IsNaN(r.Time) || math.IsInf(r.Time, 0) {
+		return errors.New("invalid time")
+	}
+	if math.IsNaN(r.Amount) || math.IsInf(r.Amount, 0) || r.Amount < 0 {
+		return errors.New("invalid amount")
+	}
+	return nil
+}
+
+// scaledAmount returns amount multiplied by scale and rounded to an integer.
+// It returns false if the result isn't representable as uint64.
+func scaledAmount(amount float64, scale float64) (uint64, bool) {
+	scaled := math.Round(amount * scale)
+	// float64(math.MaxUint64) is 2^64, which doesn't fit.
+	if math.IsNaN(scaled) || scaled < 0 || scaled >= float64(math.MaxUint64) {
+		return 0, false
+	}
+	return uint64(scaled), true
+}
+
+// address returns index of client mapped to the label.
+// It returns false if all clients are already mapped to other labels.
+func (t *Trace) address(label string, numberOfClients int) (int, bool) {
+	if address, exists := t.addresses[label]; exists {
+		return address, true
+	}
+	if len(t.addresses) >= numberOfClients {
+		return 0, false
+	}
+	address := len(t.addresses)
+	t.addresses[label] = address
+	return address, true
+}
+
+// Next returns records for the block at the height.
+func (t *Trace) Next(height uint64) []Record {
+	end := t.start + float64(height)*t.interval
+	first := t.next
+	for t.next < len(t.records) && t.records[t.next].Time < end {
+		t.next++
+	}
+	return t.records[first:t.next]
+}
+
+// replay returns transactions replaying records of trace for the block at the height.
+// Records of the same sender in a block are paid by a transaction.
+// Records whose sender or receiver can't be mapped to a client or has left, whose amount is 0 or
+// out of range, which are paid to sender itself, or which overflow total amount of the sender are counted as unmatched.
+// Transactions senders can't afford are counted as unaffordable.
+func (g *Generator) replay(height uint64) ([]*models.Transaction, Stats) {
+	stats := Stats{Issued: map[string]int{}}
+	var senders []int
+	payments := map[int][]models.Payment{}
+	totals := map[int]uint64{}
+	for _, record := range g.trace.Next(height) {
+		sender, ok1 := g.trace.address(record.Sender, len(g.clients))
+		receiver, ok2 := g.trace.address(record.Receiver, len(g.clients))
+		amount, ok3 := scaledAmount(record.Amount, g.trace.amountScale)
+		if !ok1 || !ok2 || !ok3 || g.left[sender] || g.left[receiver] || sender == receiver || amount == 0 ||
+			totals[sender]+amount < totals[sender] {
+			stats.Unmatched++
+			continue
+		}
+		if _, exists := payments[sender]; !exists {
+			senders = append(senders, sender)
+		}
+		payments[sender] = append(payments[sender], models.Payment{Client: g.clients[receiver], Amount: amount})
+		totals[sender] += amount
+	}
+	stats.Senders = len(senders)
+
+	var txs []*models.Transaction
+	for _, sender := range senders {
+		recipients := payments[sender]
+		senderPayments := []models.Payment{{Client: g.clients[sender], Amount: totals[sender]}}
+		tx, err := models.BuildMultiPartyTransaction(senderPayments, recipients, g.selector)
+		if err != nil {
+			stats.Unaffordable++
+			continue
+		}
+		g.countMemoryFreed(tx, senderPayments, recipients, &stats)
+		if len(recipients) == 1 {
+			stats.Issued[Payment]++
+		} else {
+			stats.Issued[Batch]++
+		}
+		txs = append(txs, tx)
+	}
+	return txs, stats
+}
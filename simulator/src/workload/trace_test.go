@@ -0,0 +1,181 @@
+package workload
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"trail_simulator/simulator/src/models"
+)
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []Record
+		wantErr bool
+	}{
+		{
+			name:  "header and records",
+			input: "time,sender,receiver,amount\n1.5, alice, bob, 10\n2,bob,carol,0.25\n",
+			want:  []Record{{1.5, "alice", "bob", 10}, {2, "bob", "carol", 0.25}},
+		},
+		{
+			name:  "without header",
+			input: "1,alice,bob,10\n",
+			want:  []Record{{1, "alice", "bob", 10}},
+		},
+		{
+			name:    "invalid time after header",
+			input:   "time,sender,receiver,amount\nnow,alice,bob,10\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid amount",
+			input:   "1,alice,bob,ten\n",
+			wantErr: true,
+		},
+		{
+			name:    "negative amount",
+			input:   "1,alice,bob,-5\n",
+			wantErr: true,
+		},
+		{
+			name:    "NaN amount",
+			input:   "1,alice,bob,NaN\n",
+			wantErr: true,
+		},
+		{
+			name:    "infinite amount",
+			input:   "1,alice,bob,Inf\n",
+			wantErr: true,
+		},
+		{
+			name:    "infinite time",
+			input:   "1,alice,bob,10\n-Inf,alice,bob,10\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing column",
+			input:   "1,alice,bob\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readCSV(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readCSV() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readCSV() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadNDJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []Record
+		wantErr bool
+	}{
+		{
+			name:  "records with string and number labels",
+			input: "{\"time\":1,\"sender\":\"alice\",\"receiver\":2,\"amount\":10}\n\n{\"time\":2,\"sender\":2,\"receiver\":\"alice\",\"amount\":1e30}\n",
+			want:  []Record{{1, "alice", "2", 10}, {2, "2", "alice", 1e30}},
+		},
+		{
+			name:    "invalid JSON",
+			input:   "{\"time\":1,\n",
+			wantErr: true,
+		},
+		{
+			name:    "no receiver",
+			input:   "{\"time\":1,\"sender\":\"alice\",\"amount\":10}\n",
+			wantErr: true,
+		},
+		{
+			name:    "negative amount",
+			input:   "{\"time\":1,\"sender\":\"alice\",\"receiver\":\"bob\",\"amount\":-5}\n",
+			wantErr: true,
+		},
+		{
+			name:    "amount out of range of float64",
+			input:   "{\"time\":1,\"sender\":\"alice\",\"receiver\":\"bob\",\"amount\":1e400}\n",
+			wantErr: true,
+		},
+		{
+			name:    "amount of string",
+			input:   "{\"time\":1,\"sender\":\"alice\",\"receiver\":\"bob\",\"amount\":\"NaN\"}\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readNDJSON(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readNDJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readNDJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScaledAmount(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount float64
+		scale  float64
+		want   uint64
+		wantOK bool
+	}{
+		{"rounded", 2.5, 1, 3, true},
+		{"scaled", 0.25, 100, 25, true},
+		{"largest exact float64 below 2^64", 18446744073709549568, 1, 18446744073709549568, true},
+		{"2^64", 18446744073709551616, 1, 0, false},
+		{"too large after scaling", 1e30, 1e-5, 0, false},
+		{"negative", -1, 1, 0, false},
+		{"NaN", math.NaN(), 1, 0, false},
+		{"infinity", math.Inf(1), 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := scaledAmount(tt.amount, tt.scale)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("scaledAmount() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerator_replay(t *testing.T) {
+	selector, _ := models.NewCoinSelector("largest-first")
+	g := &Generator{
+		clients:  []*models.Client{models.NewClient(0), models.NewClient(1), models.NewClient(2)},
+		selector: selector,
+		left:     map[int]bool{},
+		trace: &Trace{
+			records: []Record{
+				{0, "alice", "bob", 1e19},
+				{0, "alice", "carol", 1e30}, // out of range.
+				{0, "alice", "carol", 1e19}, // total of alice overflows.
+				{0, "alice", "alice", 10},   // paid to sender itself.
+				{0, "alice", "dave", 10},    // no client left for dave.
+				{0, "bob", "carol", 0.4},    // 0 after rounding.
+				{1, "carol", "alice", 10},   // in the next block.
+			},
+			interval:    1,
+			amountScale: 1,
+			addresses:   map[string]int{}},
+	}
+	// clients have no TXOs, so matched records are unaffordable.
+	_, stats := g.replay(1)
+	if stats.Unmatched != 5 || stats.Senders != 1 || stats.Unaffordable != 1 {
+		t.Errorf("replay() = %d unmatched, %d senders and %d unaffordable, want 5, 1 and 1",
+			stats.Unmatched, stats.Senders, stats.Unaffordable)
+	}
+}
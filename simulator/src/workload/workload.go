@@ -0,0 +1,273 @@
+package workload
+
+import (
+	"errors"
+	"math/rand"
+	"trail_simulator/simulator/src/models"
+	"trail_simulator/simulator/src/setting"
+)
+
+// Shapes of transactions.
+const (
+	Merge         = "merge"         // two clients spend all their TXOs and split the total in half.
+	Payment       = "payment"       // a client pays another client.
+	Batch         = "batch"         // a client pays several clients, like batch payouts and exchange withdrawals.
+	MultiSender   = "multi-sender"  // several clients pay a client together.
+	Consolidation = "consolidation" // a client merges its TXOs into a TXO.
+)
+
+// Stats is the result of transactions clients tried to issue for a block.
+type Stats struct {
+	Senders          int            `json:"senders"`            // number of senders arrival pattern decided.
+	Issued           map[string]int `json:"issued"`             // number of transactions by shape.
+	Failed           int            `json:"failed"`             // transactions clients couldn't build.
+	Unmatched        int            `json:"unmatched"`          // trace records not mapped to clients.
+	Unaffordable     int            `json:"unaffordable"`       // transactions of trace records senders couldn't pay.
+	MemoryFreed      int            `json:"memory_freed"`       // branch updates senders no longer need after spending selected TXOs.
+	MemoryFreedNaive int            `json:"memory_freed_naive"` // branch updates freed if senders selected TXOs in largest-first order.
+}
+
+// Generator issues transactions of clients.
+type Generator struct {
+	clients        []*models.Client
+	selector       models.CoinSelector
+	naiveSelector  models.CoinSelector
+	trace          *Trace
+	amounts        AmountDistribution
+	arrival        ArrivalPattern
+	sendWeights    []float64
+	receiveWeights []float64
+	left           map[int]bool // indexes of clients which left.
+	shapes         []string
+	weights        []int
+}
+
+// NewGenerator provides generator which issues transactions of the clients
+// with workload model and coin selection strategy of setting.
+func NewGenerator(clients []*models.Client) (*Generator, error) {
+	selector, err := models.NewCoinSelector(setting.CoinSelection)
+	if err != nil {
+		return nil, err
+	}
+	naiveSelector, _ := models.NewCoinSelector("largest-first")
+	activity, err := NewActivity(setting.Workload)
+	if err != nil {
+		return nil, err
+	}
+	amounts, err := NewAmountDistribution(setting.AmountDistribution)
+	if err != nil {
+		return nil, err
+	}
+	arrival, err := NewArrivalPattern(setting.ArrivalPattern)
+	if err != nil {
+		return nil, err
+	}
+	g := &Generator{
+		clients:       append([]*models.Client{}, clients...),
+		selector:      selector,
+		naiveSelector: naiveSelector,
+		amounts:       amounts,
+		arrival:       arrival,
+		left:          map[int]bool{}}
+	send, receive := activity.Weights(len(clients))
+	g.sendWeights = append([]float64{}, send...)
+	g.receiveWeights = append([]float64{}, receive...)
+	if setting.TraceFile != "" {
+		g.trace, err = LoadTrace(setting.TraceFile, setting.TraceBlockInterval, setting.TraceAmountScale)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for shape, weight := range map[string]int{
+		Merge:         setting.MergeWeight,
+		Payment:       setting.PaymentWeight,
+		Batch:         setting.BatchWeight,
+		MultiSender:   setting.MultiSenderWeight,
+		Consolidation: setting.ConsolidationWeight,
+	} {
+		if weight < 0 {
+			return nil, errors.New("NewGenerator: weight of " + shape + " must not be negative")
+		}
+		if weight > 0 {
+			g.shapes = append(g.shapes, shape)
+			g.weights = append(g.weights, weight)
+		}
+	}
+	if len(g.shapes) == 0 {
+		return nil, errors.New("NewGenerator: no transaction shape has weight")
+	}
+	return g, nil
+}
+
+// Join adds client joining mid-simulation with activity weights of a random client which hasn't left.
+func (g *Generator) Join(client *models.Client) {
+	var active []int
+	for i := range g.clients {
+		if !g.left[i] {
+			active = append(active, i)
+		}
+	}
+	send, receive := 1.0, 1.0
+	if len(active) > 0 {
+		i := active[rand.Intn(len(active))]
+		send, receive = g.sendWeights[i], g.receiveWeights[i]
+	}
+	g.clients = append(g.clients, client)
+	g.sendWeights = append(g.sendWeights, send)
+	g.receiveWeights = append(g.receiveWeights, receive)
+}
+
+// Leave stops client from sending and receiving.
+func (g *Generator) Leave(client *models.Client) {
+	for i, c := range g.clients {
+		if c == client {
+			g.left[i] = true
+			g.sendWeights[i] = 0
+			g.receiveWeights[i] = 0
+		}
+	}
+}
+
+// participants returns the number of senders and recipients of the shape.
+func participants(shape string) (int, int) {
+	switch shape {
+	case Batch:
+		return 1, setting.BatchRecipients
+	case MultiSender:
+		return setting.MultiSenders, 1
+	case Merge:
+		return 2, 0
+	case Consolidation:
+		return 1, 0
+	}
+	return 1, 1
+}
+
+func (g *Generator) pickShape() string {
+	total := 0
+	for _, weight := range g.weights {
+		total += weight
+	}
+	r := rand.Intn(total)
+	for i, weight := range g.weights {
+		if r < weight {
+			return g.shapes[i]
+		}
+		r -= weight
+	}
+	return g.shapes[len(g.shapes)-1]
+}
+
+// Transactions returns transactions clients issue for the block at the height.
+// Clients issue transactions until the number of senders reaches the one of arrival pattern.
+// Senders and recipients are picked in proportion to activity weights.
+// Each client sends in at most one transaction, while a client may receive in several transactions.
+// If trace file is set, transactions replay records of the trace instead.
+func (g *Generator) Transactions(height uint64) ([]*models.Transaction, Stats) {
+	if g.trace != nil {
+		return g.replay(height)
+	}
+	stats := Stats{
+		Senders: g.arrival.Senders(height, setting.InputsPerBlock),
+		Issued:  map[string]int{}}
+	var txs []*models.Transaction
+	sent := map[int]bool{}
+	for len(sent) < stats.Senders {
+		shape := g.pickShape()
+		numberOfSenders, numberOfRecipients := participants(shape)
+		var senders, recipients []*models.Client
+		joined := map[int]bool{}
+		for i := 0; i < numberOfSenders; i++ {
+			j := pickWeighted(g.sendWeights, sent)
+			if j < 0 {
+				break
+			}
+			sent[j] = true
+			joined[j] = true
+			senders = append(senders, g.clients[j])
+		}
+		for i := 0; i < numberOfRecipients; i++ {
+			j := pickWeighted(g.receiveWeights, joined)
+			if j < 0 {
+				break
+			}
+			joined[j] = true
+			recipients = append(recipients, g.clients[j])
+		}
+		if len(senders) < numberOfSenders || len(recipients) < numberOfRecipients {
+			break
+		}
+
+		tx, err := g.build(shape, senders, recipients, &stats)
+		if err != nil {
+			stats.Failed++
+			continue
+		}
+		stats.Issued[shape]++
+		txs = append(txs, tx)
+	}
+	return txs, stats
+}
+
+func (g *Generator) build(shape string, senders []*models.Client, recipients []*models.Client, stats *Stats) (*models.Transaction, error) {
+	switch shape {
+	case Merge:
+		return models.BuildTransaction(senders[0], senders[1])
+	case Consolidation:
+		return models.BuildConsolidation(senders[0])
+	}
+
+	var senderPayments, recipientPayments []models.Payment
+	total := uint64(0)
+	if shape == MultiSender {
+		for _, sender := range senders {
+			a := g.amounts.Amount(sender.Balance(sender.HeadBlock) / 2)
+			if a == 0 {
+				return nil, errors.New("build: sender has no balance")
+			}
+			senderPayments = append(senderPayments, models.Payment{Client: sender, Amount: a})
+			total += a
+		}
+		recipientPayments = []models.Payment{{Client: recipients[0], Amount: total}}
+	} else {
+		sender := senders[0]
+		balance := sender.Balance(sender.HeadBlock) / 2
+		for _, recipient := range recipients {
+			a := g.amounts.Amount(balance / uint64(len(recipients)))
+			if a == 0 {
+				return nil, errors.New("build: sender has no balance")
+			}
+			recipientPayments = append(recipientPayments, models.Payment{Client: recipient, Amount: a})
+			total += a
+		}
+		senderPayments = []models.Payment{{Client: sender, Amount: total}}
+	}
+
+	tx, err := models.BuildMultiPartyTransaction(senderPayments, recipientPayments, g.selector)
+	if err != nil {
+		return nil, err
+	}
+	g.countMemoryFreed(tx, senderPayments, recipientPayments, stats)
+	return tx, nil
+}
+
+// countMemoryFreed adds memory senders free by the transaction and by naive selection to stats.
+// Naive selection uses the fee function each sender selected TXOs of the transaction with.
+func (g *Generator) countMemoryFreed(tx *models.Transaction, senderPayments []models.Payment, recipientPayments []models.Payment, stats *Stats) {
+	spent := make([][]*models.TXO, len(senderPayments))
+	for i, sender := range senderPayments {
+		for _, proof := range tx.Inputs {
+			if proof.TXO.OwnerAddress == sender.Client.Address {
+				spent[i] = append(spent[i], proof.TXO)
+			}
+		}
+	}
+	otherInputs := len(tx.Inputs) - len(spent[0])
+	for i, sender := range senderPayments {
+		stats.MemoryFreed += models.MemoryFreed(sender.Client, spent[i])
+		fee := models.SenderFee(i, len(senderPayments), len(recipientPayments), otherInputs)
+		if naive, err := g.naiveSelector.Select(sender.Client, sender.Amount, fee); err == nil {
+			stats.MemoryFreedNaive += models.MemoryFreed(sender.Client, naive)
+		}
+	}
+}